@@ -17,27 +17,31 @@ limitations under the License.
 package main
 
 import (
-	"cdi_dra/pkg/config"
+	"cdi_dra/pkg/client"
+	cfg "cdi_dra/pkg/config"
+	"cdi_dra/pkg/logging"
 	"cdi_dra/pkg/manager"
+	"cdi_dra/pkg/tracing"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"regexp"
-	"runtime"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-const (
-	uuidFormat = "^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$"
-)
+const shutdownTimeOut = 10 * time.Second
 
 func main() {
 	if err := newApp().Run(os.Args); err != nil {
@@ -47,14 +51,55 @@ func main() {
 }
 
 func newApp() *cli.App {
-	config := &config.Config{}
+	config := &cfg.Config{}
 	cliFlags := []cli.Flag{
-		&cli.IntFlag{
+		&cli.StringFlag{
+			Name:    "config",
+			Usage:   "Path of YAML file whose keys are the names of flags, e.g. scan-interval: 30s. Flags and environment variables take precedence over it",
+			EnvVars: []string{"CONFIG_FILE"},
+		},
+		altsrc.NewIntFlag(&cli.IntFlag{
 			Name:        "v",
 			Usage:       "Set the log level, CDI_DRA will only log message whose level is higher than this value. Default is 0.\n CDI_DRA logs error at level 8, logs warning at level 4, logs info at level 0 and logs debug at level -4. \n If log level is set larger than 8, CDI_DRA will not log any messages.",
 			Destination: &config.LogLevel,
-		},
-		&cli.DurationFlag{
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "log-level",
+			Usage:   "Set the log level by name, debug, info, warn or error. It overrides -v if set by a flag or environment variable, while -v overrides it in the config file",
+			EnvVars: []string{"LOG_LEVEL"},
+			Action: func(ctx *cli.Context, levelName string) error {
+				level, err := logging.ParseLevel(levelName)
+				if err != nil {
+					return err
+				}
+				config.LogLevel = int(level)
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "log-format",
+			Usage:       "Format of logs, text or json",
+			Destination: &config.LogFormat,
+			EnvVars:     []string{"LOG_FORMAT"},
+			Value:       logging.FormatText,
+			Action: func(ctx *cli.Context, format string) error {
+				if !slices.Contains(logging.Formats, format) {
+					return fmt.Errorf("log format must be set as one of %v", logging.Formats)
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "log-subsystem-levels",
+			Usage:       fmt.Sprintf("Log levels of subsystems overriding the log level, e.g. client=debug,manager=warn. Subsystems are %v", logging.Subsystems),
+			Destination: &config.LogSubsystemLevels,
+			EnvVars:     []string{"LOG_SUBSYSTEM_LEVELS"},
+			Action: func(ctx *cli.Context, levels string) error {
+				_, err := logging.ParseSubsystemLevels(levels)
+				return err
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
 			Name:        "scan-interval",
 			Usage:       "How often CDI resource pool is checked for renewing ResourceSlice. Its format can be set as ZZs. It must be set from 5s to 86400s",
 			Destination: &config.ScanInterval,
@@ -66,69 +111,485 @@ func newApp() *cli.App {
 				}
 				return nil
 			},
-		},
-		&cli.StringFlag{
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "fabric-scan-interval-min",
+			Usage:       "Enables per-fabric polling of available devices. A fabric is polled at this interval after its devices change, and less often while they are stable. Node-level inputs are still refreshed at the scan interval. It must be set from 1s to 86400s",
+			Destination: &config.FabricScanIntervalMin,
+			EnvVars:     []string{"FABRIC_SCAN_INTERVAL_MIN"},
+			Action: func(ctx *cli.Context, interval time.Duration) error {
+				if interval < 1*time.Second || 86400*time.Second < interval {
+					return fmt.Errorf("fabric scan interval min must be set from 1s to 86400s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "fabric-scan-interval-max",
+			Usage:       "The longest interval of per-fabric polling while available devices are stable. Default is the scan interval. It must be set from fabric-scan-interval-min to 86400s",
+			Destination: &config.FabricScanIntervalMax,
+			EnvVars:     []string{"FABRIC_SCAN_INTERVAL_MAX"},
+			Action: func(ctx *cli.Context, interval time.Duration) error {
+				min := ctx.Duration("fabric-scan-interval-min")
+				if min == 0 {
+					return fmt.Errorf("fabric scan interval max can be set only when FABRIC_SCAN_INTERVAL_MIN is set")
+				}
+				if interval < min || 86400*time.Second < interval {
+					return fmt.Errorf("fabric scan interval max must be set from fabric scan interval min to 86400s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "fm-events",
+			Usage:       "Experimental. Subscribe to notifications of reserved resource changes from Fabric Manager with Server-Sent Events, and update the pools of the notified fabric immediately. Polling is kept as a safety net. Set it only if Fabric Manager serves the events endpoint, which is not part of its published API yet",
+			Destination: &config.FMEvents,
+			EnvVars:     []string{"FM_EVENTS"},
+			Value:       false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "tenant-id",
-			Usage:       "ID of tenant where a cluster belongs. Must specify a form of UUID",
-			Required:    true,
+			Usage:       "ID of tenant where a cluster belongs. Must specify a form of UUID. Not used in multi-tenant mode",
+			Required:    false,
 			Destination: &config.TenantID,
 			EnvVars:     []string{"TENANT_ID"},
 			Action: func(ctx *cli.Context, tenantId string) error {
-				r := regexp.MustCompile(uuidFormat)
+				r := regexp.MustCompile(cfg.UUIDFormat)
 				if !r.MatchString(tenantId) {
 					return fmt.Errorf("tenant id must be set as uuid format")
 				}
 				return nil
 			},
-		},
-		&cli.StringFlag{
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "cluster-id",
-			Usage:       "ID of cluster where CDI_DRA is executed. Must specify a form of UUID",
+			Usage:       "ID of cluster where CDI_DRA is executed. Must specify a form of UUID. Not used in multi-tenant mode",
 			Required:    false,
 			Destination: &config.ClusterID,
 			EnvVars:     []string{"CLUSTER_ID"},
 			Action: func(ctx *cli.Context, clusterId string) error {
-				if ctx.Bool("use-cm") {
-					r := regexp.MustCompile(uuidFormat)
+				if ctx.Bool("use-cm") && !ctx.Bool("multi-tenant") {
+					r := regexp.MustCompile(cfg.UUIDFormat)
 					if !r.MatchString(clusterId) {
 						return fmt.Errorf("cluster id must be set as uuid format")
 					}
 				}
 				return nil
 			},
-		},
-		&cli.StringFlag{
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:        "cdi-endpoint",
-			Usage:       "Endpoint of CDI API server. Must specify host name where working CDI manager",
-			Required:    true,
+			Usage:       "Endpoint URL of CDI API server, optionally with a base path of a gateway, e.g. https://cdi.example.com/api. Required except for validate",
 			Destination: &config.CDIEndpoint,
 			EnvVars:     []string{"CDI_ENDPOINT"},
 			Action: func(ctx *cli.Context, endpoint string) error {
 				if len(endpoint) > 1000 {
 					return fmt.Errorf("cdi endpoint length must be set within 1000 bytes")
 				}
-				if !strings.HasPrefix(endpoint, "https://") {
-					return fmt.Errorf("cdi endpoint format must be set starting https://")
-				} else {
-					config.CDIEndpoint = strings.TrimPrefix(endpoint, "https://")
+				_, err := client.ParseEndpoint(endpoint, ctx.Bool("allow-insecure-http"))
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "im-endpoint",
+			Usage:       "Endpoint URL of ID Manager when it runs apart from cdi-endpoint. Its CA is read from im_certificate if set, otherwise from certificate",
+			Destination: &config.IMEndpoint,
+			EnvVars:     []string{"IM_ENDPOINT"},
+			Action: func(ctx *cli.Context, endpoint string) error {
+				if len(endpoint) > 1000 {
+					return fmt.Errorf("im endpoint length must be set within 1000 bytes")
+				}
+				_, err := client.ParseEndpoint(endpoint, ctx.Bool("allow-insecure-http"))
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "fm-endpoint",
+			Usage:       "Endpoint URL of Fabric Manager when it runs apart from cdi-endpoint. Its CA is read from fm_certificate if set, otherwise from certificate",
+			Destination: &config.FMEndpoint,
+			EnvVars:     []string{"FM_ENDPOINT"},
+			Action: func(ctx *cli.Context, endpoint string) error {
+				if len(endpoint) > 1000 {
+					return fmt.Errorf("fm endpoint length must be set within 1000 bytes")
+				}
+				_, err := client.ParseEndpoint(endpoint, ctx.Bool("allow-insecure-http"))
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "cm-endpoint",
+			Usage:       "Endpoint URL of Cluster Manager when it runs apart from cdi-endpoint. Its CA is read from cm_certificate if set, otherwise from certificate",
+			Destination: &config.CMEndpoint,
+			EnvVars:     []string{"CM_ENDPOINT"},
+			Action: func(ctx *cli.Context, endpoint string) error {
+				if len(endpoint) > 1000 {
+					return fmt.Errorf("cm endpoint length must be set within 1000 bytes")
+				}
+				_, err := client.ParseEndpoint(endpoint, ctx.Bool("allow-insecure-http"))
+				return err
+			},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "allow-insecure-http",
+			Usage:       "Whether to allow http:// for cdi-endpoint, im-endpoint, fm-endpoint and cm-endpoint. Only for lab environments, since credentials and tokens are sent in plain text",
+			Destination: &config.AllowInsecureHTTP,
+			EnvVars:     []string{"ALLOW_INSECURE_HTTP"},
+			Value:       false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "otlp-endpoint",
+			Usage:       "URL of OTLP/HTTP collector to export traces, e.g. http://otel-collector:4318. Tracing is disabled if empty",
+			Destination: &config.OTLPEndpoint,
+			EnvVars:     []string{"OTLP_ENDPOINT"},
+			Action: func(ctx *cli.Context, endpoint string) error {
+				if len(endpoint) > 1000 {
+					return fmt.Errorf("otlp endpoint length must be set within 1000 bytes")
+				}
+				_, err := tracing.ParseEndpoint(endpoint)
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "request-id-header",
+			Usage:       "HTTP header to send request IDs to CDI and to receive the ones of CDI for correlating logs. Not sent if empty",
+			Destination: &config.RequestIDHeader,
+			EnvVars:     []string{"REQUEST_ID_HEADER"},
+			Value:       client.DefaultRequestIDHeader,
+			Action: func(ctx *cli.Context, header string) error {
+				if len(header) == 0 {
+					return nil
+				}
+				if errs := validation.IsHTTPHeaderName(header); len(errs) > 0 {
+					return fmt.Errorf("request id header must be set as HTTP header name: %s", strings.Join(errs, ", "))
 				}
 				return nil
 			},
-		},
-		&cli.BoolFlag{
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "use-capi-bmh",
 			Usage:       "Whether to use cluster-api and BareMetalHost or not to get machine uuid",
 			Destination: &config.UseCapiBmh,
 			EnvVars:     []string{"USE_CAPI_BMH"},
 			Value:       false,
-		},
-		&cli.BoolFlag{
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:        "use-cm",
 			Usage:       "Whether to use Cluster Manager or not to get a device's max/min number",
 			Destination: &config.UseCM,
 			EnvVars:     []string{"USE_CM"},
 			Value:       false,
-		},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Whether to compute ResourceSlices and node labels without writing them to the cluster. The changes are logged every loop",
+			Destination: &config.DryRun,
+			EnvVars:     []string{"DRY_RUN"},
+			Value:       false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "dry-run-report",
+			Usage:       "Path of JSON file to write the changes of the latest loop to in dry-run mode",
+			Destination: &config.DryRunReport,
+			EnvVars:     []string{"DRY_RUN_REPORT"},
+			Action: func(ctx *cli.Context, path string) error {
+				if !ctx.Bool("dry-run") {
+					return fmt.Errorf("dry-run report can be set only when DRY_RUN is true")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "shutdown-grace-period",
+			Usage:       "How long the loop in flight is waited for on SIGTERM before it is interrupted. It must be set from 1s to 300s. terminationGracePeriodSeconds of the Pod should be longer than this by 10s",
+			Destination: &config.ShutdownGracePeriod,
+			EnvVars:     []string{"SHUTDOWN_GRACE_PERIOD"},
+			Value:       cfg.DefaultShutdownGracePeriod,
+			Action: func(ctx *cli.Context, gracePeriod time.Duration) error {
+				if gracePeriod < 1*time.Second || 300*time.Second < gracePeriod {
+					return fmt.Errorf("shutdown grace period must be set from 1s to 300s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "withdraw-pools-on-shutdown",
+			Usage:       "Delete the ResourceSlices of CDI fabric devices on shutdown. Set it when CDI_DRA is being uninstalled",
+			Destination: &config.WithdrawPoolsOnShutdown,
+			EnvVars:     []string{"WITHDRAW_POOLS_ON_SHUTDOWN"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "auth-method",
+			Usage:       fmt.Sprintf("Method to authenticate CDI_DRA to ID Manager. One of %s. If not set, auth_method in the Secret is used, and password is used by default", strings.Join(client.AuthMethods, ", ")),
+			Destination: &config.AuthMethod,
+			EnvVars:     []string{"AUTH_METHOD"},
+			Action: func(ctx *cli.Context, authMethod string) error {
+				if !slices.Contains(client.AuthMethods, authMethod) {
+					return fmt.Errorf("auth method must be one of %s", strings.Join(client.AuthMethods, ", "))
+				}
+				return nil
+			},
+		}),
+		altsrc.NewFloat64Flag(&cli.Float64Flag{
+			Name:        "token-refresh-fraction",
+			Usage:       "Fraction of the IM token lifetime after which the token is renewed in background. It must be set greater than 0 and less than 1",
+			Destination: &config.TokenRefreshFraction,
+			EnvVars:     []string{"TOKEN_REFRESH_FRACTION"},
+			Value:       client.DefaultTokenRefreshFraction,
+			Action: func(ctx *cli.Context, fraction float64) error {
+				if fraction <= 0 || 1 <= fraction {
+					return fmt.Errorf("token refresh fraction must be set greater than 0 and less than 1")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "verify-im-token",
+			Usage:       "Whether to verify the signature and claims of IM access tokens with JWKS of the realm",
+			Destination: &config.VerifyIMToken,
+			EnvVars:     []string{"VERIFY_IM_TOKEN"},
+			Value:       false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "im-token-issuer",
+			Usage:       "Expected issuer of IM access tokens. Required if verify-im-token is set",
+			Destination: &config.IMTokenIssuer,
+			EnvVars:     []string{"IM_TOKEN_ISSUER"},
+			Action: func(ctx *cli.Context, issuer string) error {
+				if len(issuer) > 1000 {
+					return fmt.Errorf("im token issuer length must be set within 1000 bytes")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "im-token-audience",
+			Usage:       "Expected audience of IM access tokens. If not set, the audience is not verified",
+			Destination: &config.IMTokenAudience,
+			EnvVars:     []string{"IM_TOKEN_AUDIENCE"},
+			Action: func(ctx *cli.Context, audience string) error {
+				if len(audience) > 1000 {
+					return fmt.Errorf("im token audience length must be set within 1000 bytes")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "credentials-source",
+			Usage:       fmt.Sprintf("Where credentials and certificates to connect CDI are read from. One of %s", strings.Join(client.CredentialsSources, ", ")),
+			Destination: &config.CredentialsSource,
+			EnvVars:     []string{"CREDENTIALS_SOURCE"},
+			Value:       client.CredentialsSourceSecret,
+			Action: func(ctx *cli.Context, source string) error {
+				if !slices.Contains(client.CredentialsSources, source) {
+					return fmt.Errorf("credentials source must be one of %s", strings.Join(client.CredentialsSources, ", "))
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "credentials-dir",
+			Usage:       "Directory which has a file for every key of the Secret, e.g. a projected volume. Used when credentials source is file. The files are polled at every credentials-reload-interval, so rotated files are picked up within that interval",
+			Destination: &config.CredentialsDir,
+			EnvVars:     []string{"CREDENTIALS_DIR"},
+			Value:       cfg.DefaultCredentialsDir,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "credentials-reload-interval",
+			Usage:       "How often credentials files or the Vault secret are polled when credentials source is file or vault. It must be set from 5s to 3600s",
+			Destination: &config.CredentialsReloadInterval,
+			EnvVars:     []string{"CREDENTIALS_RELOAD_INTERVAL"},
+			Value:       cfg.DefaultCredentialsReloadInterval,
+			Action: func(ctx *cli.Context, interval time.Duration) error {
+				if interval < 5*time.Second || 3600*time.Second < interval {
+					return fmt.Errorf("credentials reload interval must be set from 5s to 3600s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "vault-address",
+			Usage:       "Address of Vault compatible API, e.g. https://vault:8200. Used when credentials source is vault",
+			Destination: &config.VaultAddress,
+			EnvVars:     []string{"VAULT_ADDR"},
+			Action: func(ctx *cli.Context, address string) error {
+				if len(address) > 1000 {
+					return fmt.Errorf("vault address length must be set within 1000 bytes")
+				}
+				if !strings.HasPrefix(address, "https://") && !strings.HasPrefix(address, "http://") {
+					return fmt.Errorf("vault address format must be set starting https:// or http://")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "vault-path",
+			Usage:       "Path of the secret in Vault, e.g. secret/data/composable-dra for KV version 2. Used when credentials source is vault",
+			Destination: &config.VaultPath,
+			EnvVars:     []string{"VAULT_PATH"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "vault-token-file",
+			Usage:       "File of the Vault token. If not set, VAULT_TOKEN is used",
+			Destination: &config.VaultTokenFile,
+			EnvVars:     []string{"VAULT_TOKEN_FILE"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "tls-min-version",
+			Usage:       "Minimum TLS version to connect CDI. One of 1.2, 1.3",
+			Destination: &config.TLSMinVersion,
+			EnvVars:     []string{"TLS_MIN_VERSION"},
+			Value:       client.DefaultTLSMinVersion,
+			Action: func(ctx *cli.Context, version string) error {
+				_, err := client.ParseTLSVersion(version)
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "tls-cipher-suites",
+			Usage:       "Comma separated cipher suites for TLS 1.2, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. If not set, the default cipher suites of Go are used. Cipher suites of TLS 1.3 are not configurable",
+			Destination: &config.TLSCipherSuites,
+			EnvVars:     []string{"TLS_CIPHER_SUITES"},
+			Action: func(ctx *cli.Context, suites string) error {
+				_, err := client.ParseCipherSuites(suites)
+				return err
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "tls-server-name",
+			Usage:       "Server name to verify the certificate of CDI and to send as SNI, instead of the host of cdi-endpoint",
+			Destination: &config.TLSServerName,
+			EnvVars:     []string{"TLS_SERVER_NAME"},
+			Action: func(ctx *cli.Context, serverName string) error {
+				if errs := validation.IsDNS1123Subdomain(serverName); len(errs) > 0 {
+					return fmt.Errorf("tls server name must be set as DNS subdomain: %s", strings.Join(errs, ", "))
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "proxy-url",
+			Usage:       "URL of HTTP(S) proxy to connect CDI. If not set, CDI is connected directly and HTTPS_PROXY and NO_PROXY are ignored",
+			Destination: &config.ProxyURL,
+			EnvVars:     []string{"PROXY_URL"},
+			Action: func(ctx *cli.Context, proxyURL string) error {
+				if len(proxyURL) > 1000 {
+					return fmt.Errorf("proxy url length must be set within 1000 bytes")
+				}
+				_, err := client.ParseProxyURL(proxyURL)
+				return err
+			},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "max-idle-conns",
+			Usage:       "Maximum number of idle connections to CDI. It must be set from 1 to 1000",
+			Destination: &config.MaxIdleConns,
+			EnvVars:     []string{"MAX_IDLE_CONNS"},
+			Value:       client.DefaultMaxIdleConns,
+			Action: func(ctx *cli.Context, conns int) error {
+				if conns < 1 || 1000 < conns {
+					return fmt.Errorf("max idle conns must be set from 1 to 1000")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:        "max-idle-conns-per-host",
+			Usage:       "Maximum number of idle connections to every host of CDI. It must be set from 1 to 1000",
+			Destination: &config.MaxIdleConnsPerHost,
+			EnvVars:     []string{"MAX_IDLE_CONNS_PER_HOST"},
+			Value:       client.DefaultMaxIdleConnsPerHost,
+			Action: func(ctx *cli.Context, conns int) error {
+				if conns < 1 || 1000 < conns {
+					return fmt.Errorf("max idle conns per host must be set from 1 to 1000")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "idle-conn-timeout",
+			Usage:       "How long an idle connection to CDI is kept. It must be set from 1s to 3600s",
+			Destination: &config.IdleConnTimeout,
+			EnvVars:     []string{"IDLE_CONN_TIMEOUT"},
+			Value:       client.DefaultIdleConnTimeout,
+			Action: func(ctx *cli.Context, timeout time.Duration) error {
+				if timeout < 1*time.Second || 3600*time.Second < timeout {
+					return fmt.Errorf("idle conn timeout must be set from 1s to 3600s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "dial-timeout",
+			Usage:       "Timeout to establish a connection to CDI. It must be set from 1s to 60s",
+			Destination: &config.DialTimeout,
+			EnvVars:     []string{"DIAL_TIMEOUT"},
+			Value:       client.DefaultDialTimeout,
+			Action: func(ctx *cli.Context, timeout time.Duration) error {
+				if timeout < 1*time.Second || 60*time.Second < timeout {
+					return fmt.Errorf("dial timeout must be set from 1s to 60s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:        "tls-handshake-timeout",
+			Usage:       "Timeout of TLS handshake with CDI. It must be set from 1s to 60s",
+			Destination: &config.TLSHandshakeTimeout,
+			EnvVars:     []string{"TLS_HANDSHAKE_TIMEOUT"},
+			Value:       client.DefaultTLSHandshakeTimeout,
+			Action: func(ctx *cli.Context, timeout time.Duration) error {
+				if timeout < 1*time.Second || 60*time.Second < timeout {
+					return fmt.Errorf("tls handshake timeout must be set from 1s to 60s")
+				}
+				return nil
+			},
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:        "multi-tenant",
+			Usage:       "Whether to serve several CDI tenants from one driver. Tenants are read from tenant-info in the ConfigMap instead of tenant-id and cluster-id",
+			Destination: &config.MultiTenant,
+			EnvVars:     []string{"MULTI_TENANT"},
+			Value:       false,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "namespace",
+			Usage:       "Namespace where the ConfigMap and Secret for CDI_DRA exist. Defaults to the namespace of the pod given by Downward API",
+			Destination: &config.Namespace,
+			EnvVars:     []string{"NAMESPACE", "POD_NAMESPACE"},
+			Value:       cfg.DefaultNamespace,
+			Action: func(ctx *cli.Context, namespace string) error {
+				if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+					return fmt.Errorf("namespace must be set as DNS label: %s", strings.Join(errs, ", "))
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "configmap-name",
+			Usage:       "Name of the ConfigMap which has device-info and label-prefix",
+			Destination: &config.ConfigMapName,
+			EnvVars:     []string{"CONFIGMAP_NAME"},
+			Value:       cfg.DefaultConfigMapName,
+			Action: func(ctx *cli.Context, name string) error {
+				if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+					return fmt.Errorf("configmap name must be set as DNS subdomain: %s", strings.Join(errs, ", "))
+				}
+				return nil
+			},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:        "secret-name",
+			Usage:       "Name of the Secret which has credentials and certificate to connect CDI API server",
+			Destination: &config.SecretName,
+			EnvVars:     []string{"SECRET_NAME"},
+			Value:       cfg.DefaultSecretName,
+			Action: func(ctx *cli.Context, name string) error {
+				if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+					return fmt.Errorf("secret name must be set as DNS subdomain: %s", strings.Join(errs, ", "))
+				}
+				return nil
+			},
+		}),
 	}
 
 	app := &cli.App{
@@ -136,10 +597,38 @@ func newApp() *cli.App {
 		Usage:           "cdi-dra implements a DRA driver for CDI fabric devices",
 		HideHelpCommand: true,
 		Flags:           cliFlags,
+		Commands: []*cli.Command{
+			newInspectCommand(config),
+			newValidateCommand(),
+		},
 		Before: func(c *cli.Context) error {
-			if c.Args().Len() > 0 {
+			if c.Args().Len() > 0 && c.App.Command(c.Args().First()) == nil {
 				return fmt.Errorf("arguments not supported: %v", c.Args().Slice())
 			}
+			// validate works offline without connecting to CDI
+			if c.Args().First() == "validate" {
+				return nil
+			}
+			if err := loadConfigFile(c, cliFlags); err != nil {
+				return err
+			}
+			if len(c.String("cdi-endpoint")) == 0 {
+				return fmt.Errorf("cdi endpoint must be set")
+			}
+			if c.Bool("verify-im-token") && len(c.String("im-token-issuer")) == 0 {
+				return fmt.Errorf("im token issuer must be set when VERIFY_IM_TOKEN is true")
+			}
+			if c.String("credentials-source") == client.CredentialsSourceVault {
+				if len(c.String("vault-address")) == 0 || len(c.String("vault-path")) == 0 {
+					return fmt.Errorf("vault address and vault path must be set when CREDENTIALS_SOURCE is vault")
+				}
+			}
+			if c.Bool("multi-tenant") {
+				return nil
+			}
+			if len(c.String("tenant-id")) == 0 {
+				return fmt.Errorf("tenant id must be set when MULTI_TENANT is false")
+			}
 			if c.Bool("use-cm") {
 				clusterId := c.String("cluster-id")
 				if len(clusterId) == 0 {
@@ -149,15 +638,27 @@ func newApp() *cli.App {
 			return nil
 		},
 		Action: func(c *cli.Context) error {
-			opts := &slog.HandlerOptions{
-				AddSource:   true,
-				Level:       slog.Level(config.LogLevel),
-				ReplaceAttr: replaceAttr,
+			logger, err := setDefaultLogger(config, os.Stdout)
+			if err != nil {
+				return err
 			}
-			logger := slog.New(slog.NewTextHandler(os.Stdout, opts)).With("compo", "CDI_DRA")
-			slog.SetDefault(logger)
 
 			slog.Info("CDI_DRA start")
+			slog.Info("effective config", "config", config)
+
+			shutdownTracing, err := tracing.Setup(c.Context, config.OTLPEndpoint)
+			if err != nil {
+				slog.Error("Failed to set up tracing", "error", err)
+				return err
+			}
+			defer func() {
+				// Flush the spans of the last loop
+				ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeOut)
+				defer cancel()
+				if err := shutdownTracing(ctx); err != nil {
+					slog.Warn("Failed to shut down tracing", "error", err)
+				}
+			}()
 
 			sigs := make(chan os.Signal, 1)
 			signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
@@ -175,6 +676,13 @@ func newApp() *cli.App {
 			select {
 			case s := <-sigs:
 				slog.Info("Signal received", "signal", s.String())
+				// Wait for the manager to finish the loop in flight, stop controllers and revoke IM tokens
+				cancel()
+				select {
+				case <-errChan:
+				case <-time.After(config.ShutdownGracePeriod + shutdownTimeOut):
+					slog.Warn("Manager did not stop in time")
+				}
 				return nil
 			case err := <-errChan:
 				slog.Error("Failed start manager", "error", err)
@@ -185,14 +693,145 @@ func newApp() *cli.App {
 	return app
 }
 
-func replaceAttr(_ []string, attr slog.Attr) slog.Attr {
-	if attr.Key == slog.SourceKey {
-		_, file, line, ok := runtime.Caller(6)
-		if !ok {
-			return attr
+// loadConfigFile sets the flags which are set neither by arguments nor by environment variables from the config file.
+func loadConfigFile(c *cli.Context, flags []cli.Flag) error {
+	path := c.String("config")
+	if len(path) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	// Typos must not be ignored silently
+	var flagNames []string
+	for _, flag := range flags {
+		flagNames = append(flagNames, flag.Names()...)
+	}
+	for key := range keys {
+		if key == "config" || !slices.Contains(flagNames, key) {
+			return fmt.Errorf("unknown key %q in config file", key)
 		}
-		v := fmt.Sprintf("%s:%d", filepath.Base(file), line)
-		attr.Value = slog.StringValue(v)
 	}
-	return attr
+	source, err := altsrc.NewYamlSourceFromFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	// log-level overrides -v, but -v on the command line must win over the file
+	if c.IsSet("v") {
+		flags = slices.DeleteFunc(slices.Clone(flags), func(flag cli.Flag) bool {
+			return slices.Contains(flag.Names(), "log-level")
+		})
+	}
+	if err := altsrc.ApplyInputSourceValues(c, source, flags); err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	return nil
+}
+
+// newInspectCommand returns the command printing the view of CDI once.
+func newInspectCommand(config *cfg.Config) *cli.Command {
+	usages := map[string]string{
+		manager.InspectMachines:   "Print the machine UUID and the fabric of every node",
+		manager.InspectFabrics:    "Print the nodes belonging to every fabric",
+		manager.InspectNodeGroups: "Print the nodes and the min/max number of devices of every node group in Cluster Manager",
+		manager.InspectPools:      "Print the available number of devices of every pool",
+	}
+	var subcommands []*cli.Command
+	for _, target := range manager.InspectTargets {
+		subcommands = append(subcommands, &cli.Command{
+			Name:  target,
+			Usage: usages[target],
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "output",
+					Aliases: []string{"o"},
+					Usage:   fmt.Sprintf("Output format, one of %v", manager.Outputs),
+					Value:   manager.OutputTable,
+					Action: func(ctx *cli.Context, output string) error {
+						if !slices.Contains(manager.Outputs, output) {
+							return fmt.Errorf("output must be one of %v", manager.Outputs)
+						}
+						return nil
+					},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.Args().Len() > 0 {
+					return fmt.Errorf("arguments not supported: %v", c.Args().Slice())
+				}
+				// Keep stdout for the result
+				if _, err := setDefaultLogger(config, os.Stderr); err != nil {
+					return err
+				}
+				ctx, cancel := context.WithCancel(c.Context)
+				defer cancel()
+				return manager.Inspect(ctx, config, target, c.String("output"), os.Stdout)
+			},
+		})
+	}
+	return &cli.Command{
+		Name:        "inspect",
+		Usage:       "Print the view of CDI for troubleshooting, using the same flags as the driver",
+		Subcommands: subcommands,
+	}
+}
+
+// newValidateCommand returns the command validating device-info and label-prefix files offline.
+func newValidateCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "Validate device-info, label-prefix and tenant-info in ConfigMap manifests or raw YAML files, and print every violation",
+		ArgsUsage: "FILE...",
+		Action: func(c *cli.Context) error {
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("file to validate must be set")
+			}
+			// Only violations are printed
+			slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+			var invalid int
+			for _, path := range c.Args().Slice() {
+				cm, err := cfg.LoadConfigMapFile(path)
+				if err != nil {
+					fmt.Fprintf(c.App.Writer, "%s: %v\n", path, err)
+					invalid++
+					continue
+				}
+				violations := cfg.ValidateConfigMap(cm)
+				for _, violation := range violations {
+					fmt.Fprintf(c.App.Writer, "%s: %s\n", path, violation)
+				}
+				if len(violations) > 0 {
+					invalid++
+				}
+			}
+			if invalid > 0 {
+				return cli.Exit(fmt.Sprintf("%d of %d files are invalid", invalid, c.Args().Len()), 1)
+			}
+			return nil
+		},
+	}
+}
+
+// setDefaultLogger sets the logger built from the config as the default logger.
+func setDefaultLogger(config *cfg.Config, w io.Writer) (*slog.Logger, error) {
+	subsystemLevels, err := logging.ParseSubsystemLevels(config.LogSubsystemLevels)
+	if err != nil {
+		return nil, err
+	}
+	baseLogger, err := logging.NewLogger(w, logging.Options{
+		Format:          config.LogFormat,
+		Level:           slog.Level(config.LogLevel),
+		SubsystemLevels: subsystemLevels,
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger := baseLogger.With("compo", "CDI_DRA")
+	slog.SetDefault(logger)
+	return logger, nil
 }
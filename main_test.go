@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestConfigFile(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		file                 string
+		env                  map[string]string
+		args                 []string
+		expectedScanInterval time.Duration
+		expectedUseCM        bool
+		expectedLogLevel     string
+		expectedErr          bool
+		expectedErrMsg       string
+	}{
+		{
+			name:                 "When settings are read from config file",
+			file:                 "scan-interval: 30s\nuse-cm: true\n",
+			expectedScanInterval: 30 * time.Second,
+			expectedUseCM:        true,
+		},
+		{
+			name:                 "When setting is not in config file",
+			file:                 "use-cm: true\n",
+			expectedScanInterval: time.Minute,
+			expectedUseCM:        true,
+		},
+		{
+			name:                 "When environment variable overrides config file",
+			file:                 "scan-interval: 30s\n",
+			env:                  map[string]string{"SCAN_INTERVAL": "45s"},
+			expectedScanInterval: 45 * time.Second,
+		},
+		{
+			name:                 "When flag overrides environment variable and config file",
+			file:                 "scan-interval: 30s\n",
+			env:                  map[string]string{"SCAN_INTERVAL": "45s"},
+			args:                 []string{"--scan-interval", "50s"},
+			expectedScanInterval: 50 * time.Second,
+		},
+		{
+			name:                 "When log level is read from config file",
+			file:                 "log-level: warn\n",
+			expectedScanInterval: time.Minute,
+			expectedLogLevel:     "warn",
+		},
+		{
+			name:                 "When -v overrides log level in config file",
+			file:                 "log-level: warn\n",
+			args:                 []string{"-v", "-4"},
+			expectedScanInterval: time.Minute,
+		},
+		{
+			name:                 "When log level environment variable overrides -v",
+			env:                  map[string]string{"LOG_LEVEL": "error"},
+			args:                 []string{"-v", "-4"},
+			expectedScanInterval: time.Minute,
+			expectedLogLevel:     "error",
+		},
+		{
+			name:           "When config file has unknown key",
+			file:           "scan-intreval: 30s\n",
+			expectedErr:    true,
+			expectedErrMsg: `unknown key "scan-intreval" in config file`,
+		},
+		{
+			name:           "When config file has invalid value",
+			file:           "scan-interval: 1s\n",
+			expectedErr:    true,
+			expectedErrMsg: "scan interval must be set from 5s to 86400s",
+		},
+		{
+			name:           "When fabric scan interval max is set without min",
+			file:           "fabric-scan-interval-max: 5m\n",
+			expectedErr:    true,
+			expectedErrMsg: "fabric scan interval max can be set only when FABRIC_SCAN_INTERVAL_MIN is set",
+		},
+		{
+			name:           "When fabric scan interval max is shorter than min",
+			file:           "fabric-scan-interval-min: 10s\nfabric-scan-interval-max: 5s\n",
+			expectedErr:    true,
+			expectedErrMsg: "fabric scan interval max must be set from fabric scan interval min to 86400s",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			file := "tenant-id: 00000000-0000-0001-0000-000000000000\ncluster-id: 00000000-0000-0000-0001-000000000000\ncdi-endpoint: https://cdi.example.com\n" + tc.file
+			if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			var scanInterval time.Duration
+			var useCM bool
+			var logLevel string
+			app := newApp()
+			app.Action = func(c *cli.Context) error {
+				scanInterval = c.Duration("scan-interval")
+				useCM = c.Bool("use-cm")
+				logLevel = c.String("log-level")
+				return nil
+			}
+			err := app.Run(append([]string{"cdi-dra", "--config", path}, tc.args...))
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scanInterval != tc.expectedScanInterval {
+				t.Errorf("unexpected scan interval, expected %v but got %v", tc.expectedScanInterval, scanInterval)
+			}
+			if useCM != tc.expectedUseCM {
+				t.Errorf("unexpected use-cm, expected %v but got %v", tc.expectedUseCM, useCM)
+			}
+			if logLevel != tc.expectedLogLevel {
+				t.Errorf("unexpected log level, expected %q but got %q", tc.expectedLogLevel, logLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	AuthMethodPassword          = "password"
+	AuthMethodClientCredentials = "client_credentials"
+	AuthMethodRefreshToken      = "refresh_token"
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"
+	AuthMethodTLSClientAuth     = "tls_client_auth"
+)
+
+var AuthMethods = []string{
+	AuthMethodPassword,
+	AuthMethodClientCredentials,
+	AuthMethodRefreshToken,
+	AuthMethodPrivateKeyJWT,
+	AuthMethodTLSClientAuth,
+}
+
+const (
+	clientAssertionType     = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	clientAssertionLifetime = 60 * time.Second
+)
+
+// clientAuthenticator sets the credentials of the client to the form of a request to ID manager.
+type clientAuthenticator interface {
+	authenticate(form url.Values) error
+}
+
+// authTokenSource is a token source issuing IM tokens with an auth method.
+type authTokenSource interface {
+	oauth2.TokenSource
+	clientAuthenticator
+	// tokenRequest returns the form of a token request with the grant of the auth method.
+	tokenRequest() (url.Values, error)
+}
+
+// newAuthTokenSource returns the token source for the auth method of the secret.
+func newAuthTokenSource(client *CDIClient, secret idManagerSecret) (authTokenSource, error) {
+	clientSecret := clientSecretAuth{clientID: secret.client_id, clientSecret: secret.client_secret}
+	switch secret.authMethod {
+	case AuthMethodPassword, "":
+		return &passwordTokenSource{
+			client:           client,
+			realm:            secret.realm,
+			username:         secret.username,
+			password:         secret.password,
+			clientSecretAuth: clientSecret,
+		}, nil
+	case AuthMethodClientCredentials:
+		return &clientCredentialsTokenSource{
+			client:           client,
+			realm:            secret.realm,
+			clientSecretAuth: clientSecret,
+		}, nil
+	case AuthMethodRefreshToken:
+		if len(secret.refreshToken) == 0 {
+			return nil, fmt.Errorf("refresh_token is required for auth method %s", secret.authMethod)
+		}
+		return &refreshTokenSource{
+			client:       client,
+			realm:        secret.realm,
+			refreshToken: secret.refreshToken,
+			auth:         clientSecret,
+		}, nil
+	case AuthMethodPrivateKeyJWT:
+		if len(secret.privateKey) == 0 {
+			return nil, fmt.Errorf("private_key is required for auth method %s", secret.authMethod)
+		}
+		key, err := parsePrivateKey(secret.privateKey)
+		if err != nil {
+			return nil, err
+		}
+		return &privateKeyJWTTokenSource{
+			client:   client,
+			realm:    secret.realm,
+			clientID: secret.client_id,
+			keyID:    secret.privateKeyID,
+			key:      key,
+		}, nil
+	case AuthMethodTLSClientAuth:
+		if !secret.hasClientCertificate {
+			return nil, fmt.Errorf("client_certificate is required for auth method %s", secret.authMethod)
+		}
+		return &tlsClientAuthTokenSource{
+			client:   client,
+			realm:    secret.realm,
+			clientID: secret.client_id,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth method: %s", secret.authMethod)
+	}
+}
+
+// issueIMToken gets a new IM token from ID manager of the realm with the token request.
+func issueIMToken(client *CDIClient, realm string, tokenRequest func() (url.Values, error)) (*oauth2.Token, error) {
+	form, err := tokenRequest()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(context.Background(), RequestIDKey{}, config.RandomString(6))
+	slog.Debug("trying API to get IM token", "requestID", GetRequestIdFromContext(ctx), "grantType", form.Get("grant_type"))
+	imToken, err := client.GetIMToken(ctx, realm, form)
+	if err != nil {
+		slog.Error("IM token API failed", "requestID", GetRequestIdFromContext(ctx))
+		return nil, err
+	}
+	slog.Debug("IM token API completed successfully", "requestID", GetRequestIdFromContext(ctx))
+	return newOAuth2Token(imToken)
+}
+
+// clientSecretAuth authenticates the client with its client_id and client_secret.
+type clientSecretAuth struct {
+	clientID     string
+	clientSecret string
+}
+
+func (a clientSecretAuth) authenticate(form url.Values) error {
+	form.Set("client_id", a.clientID)
+	if len(a.clientSecret) > 0 {
+		form.Set("client_secret", a.clientSecret)
+	}
+	return nil
+}
+
+// passwordTokenSource issues IM tokens with the password grant of a user.
+type passwordTokenSource struct {
+	client   *CDIClient
+	realm    string
+	username string
+	password string
+	clientSecretAuth
+}
+
+func (ts *passwordTokenSource) Token() (*oauth2.Token, error) {
+	return issueIMToken(ts.client, ts.realm, ts.tokenRequest)
+}
+
+func (ts *passwordTokenSource) tokenRequest() (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", ts.username)
+	form.Set("password", ts.password)
+	form.Set("scope", "openid")
+	form.Set("response", "id_token token")
+	return form, ts.authenticate(form)
+}
+
+// clientCredentialsTokenSource issues IM tokens with the client_credentials grant of a confidential client.
+type clientCredentialsTokenSource struct {
+	client *CDIClient
+	realm  string
+	clientSecretAuth
+}
+
+func (ts *clientCredentialsTokenSource) Token() (*oauth2.Token, error) {
+	return issueIMToken(ts.client, ts.realm, ts.tokenRequest)
+}
+
+func (ts *clientCredentialsTokenSource) tokenRequest() (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", "openid")
+	return form, ts.authenticate(form)
+}
+
+// refreshTokenSource issues IM tokens with the refresh_token grant.
+// The client is authenticated by auth, so that a refresh token issued in any auth method can be used.
+type refreshTokenSource struct {
+	client       *CDIClient
+	realm        string
+	refreshToken string
+	auth         clientAuthenticator
+}
+
+func (ts *refreshTokenSource) Token() (*oauth2.Token, error) {
+	return issueIMToken(ts.client, ts.realm, ts.tokenRequest)
+}
+
+func (ts *refreshTokenSource) tokenRequest() (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", ts.refreshToken)
+	return form, ts.authenticate(form)
+}
+
+func (ts *refreshTokenSource) authenticate(form url.Values) error {
+	return ts.auth.authenticate(form)
+}
+
+// sessionTokenSource issues IM tokens with the refresh token of the current session,
+// falling back to the auth method when the refresh token is rejected.
+type sessionTokenSource struct {
+	session  *refreshTokenSource
+	fallback oauth2.TokenSource
+	// onReject is called to discard the session before falling back
+	onReject func()
+}
+
+func (ts *sessionTokenSource) Token() (*oauth2.Token, error) {
+	token, err := ts.session.Token()
+	if err == nil {
+		if len(token.RefreshToken) == 0 {
+			// ID manager may keep the refresh token as it is in the refresh_token grant
+			token.RefreshToken = ts.session.refreshToken
+		}
+		return token, nil
+	}
+	// Other errors such as timeouts and 5xx do not tell that the session is lost, so it is kept for the next try
+	if !IsAPIErrorStatus(err, http.StatusBadRequest, http.StatusUnauthorized) {
+		return nil, err
+	}
+	// The refresh token may be revoked or the session may be expired in ID manager
+	slog.Warn("refresh token is rejected, falling back to auth method", "error", err)
+	ts.onReject()
+	return ts.fallback.Token()
+}
+
+// privateKeyJWTTokenSource issues IM tokens with the client_credentials grant,
+// authenticating the client with a JWT signed by its private key.
+type privateKeyJWTTokenSource struct {
+	client   *CDIClient
+	realm    string
+	clientID string
+	keyID    string
+	key      crypto.Signer
+}
+
+func (ts *privateKeyJWTTokenSource) Token() (*oauth2.Token, error) {
+	return issueIMToken(ts.client, ts.realm, ts.tokenRequest)
+}
+
+func (ts *privateKeyJWTTokenSource) tokenRequest() (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", "openid")
+	return form, ts.authenticate(form)
+}
+
+func (ts *privateKeyJWTTokenSource) authenticate(form url.Values) error {
+	// The token endpoint is the audience of the client assertion
+	assertion, err := newClientAssertion(ts.key, ts.clientID, ts.keyID, ts.client.imTokenURL(ts.realm))
+	if err != nil {
+		return err
+	}
+	form.Set("client_id", ts.clientID)
+	form.Set("client_assertion_type", clientAssertionType)
+	form.Set("client_assertion", assertion)
+	return nil
+}
+
+// tlsClientAuthTokenSource issues IM tokens with the client_credentials grant,
+// authenticating the client with the client certificate of mutual TLS.
+type tlsClientAuthTokenSource struct {
+	client   *CDIClient
+	realm    string
+	clientID string
+}
+
+func (ts *tlsClientAuthTokenSource) Token() (*oauth2.Token, error) {
+	return issueIMToken(ts.client, ts.realm, ts.tokenRequest)
+}
+
+func (ts *tlsClientAuthTokenSource) tokenRequest() (url.Values, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("scope", "openid")
+	return form, ts.authenticate(form)
+}
+
+func (ts *tlsClientAuthTokenSource) authenticate(form url.Values) error {
+	form.Set("client_id", ts.clientID)
+	return nil
+}
+
+// newClientAssertion creates a JWT signed with the private key of the client, as defined in RFC 7523.
+func newClientAssertion(key crypto.Signer, clientID string, keyID string, audience string) (string, error) {
+	var alg string
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		alg = "RS256"
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported curve of private key: %s", key.Curve.Params().Name)
+		}
+		alg = "ES256"
+	default:
+		return "", fmt.Errorf("unsupported private key type %T", key)
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	header := map[string]string{
+		"alg": alg,
+		"typ": "JWT",
+	}
+	if len(keyID) > 0 {
+		header["kid"] = keyID
+	}
+	claims := map[string]any{
+		"iss": clientID,
+		"sub": clientID,
+		"aud": audience,
+		"jti": base64.RawURLEncoding.EncodeToString(jti),
+		"iat": now.Unix(),
+		"exp": now.Add(clientAssertionLifetime).Unix(),
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("failed to sign client assertion: %w", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("failed to sign client assertion: %w", err)
+		}
+		// JWS uses fixed length R || S instead of ASN.1 DER
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private_key as PEM")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type of private_key: %s", block.Type)
+	}
+}
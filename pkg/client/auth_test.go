@@ -0,0 +1,273 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTokenURL = "https://localhost/id_manager/realms/CDI_DRA_Test/protocol/openid-connect/token"
+
+func TestNewAuthTokenSource(t *testing.T) {
+	privateKeyPem, _, _ := config.TestClientKeys()
+	testCases := []struct {
+		name           string
+		secret         idManagerSecret
+		expectedType   authTokenSource
+		expectedForm   map[string]string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name: "When auth method is not set",
+			secret: idManagerSecret{
+				username:      "user",
+				password:      "pass",
+				client_id:     "0001",
+				client_secret: "secret",
+			},
+			expectedType: &passwordTokenSource{},
+			expectedForm: map[string]string{
+				"grant_type":    "password",
+				"client_id":     "0001",
+				"client_secret": "secret",
+				"username":      "user",
+				"password":      "pass",
+				"scope":         "openid",
+			},
+		},
+		{
+			name: "When auth method is client_credentials",
+			secret: idManagerSecret{
+				authMethod:    AuthMethodClientCredentials,
+				client_id:     "0001",
+				client_secret: "secret",
+			},
+			expectedType: &clientCredentialsTokenSource{},
+			expectedForm: map[string]string{
+				"grant_type":    "client_credentials",
+				"client_id":     "0001",
+				"client_secret": "secret",
+				"username":      "",
+				"password":      "",
+			},
+		},
+		{
+			name: "When auth method is refresh_token",
+			secret: idManagerSecret{
+				authMethod:   AuthMethodRefreshToken,
+				client_id:    "0001",
+				refreshToken: "offline-token",
+			},
+			expectedType: &refreshTokenSource{},
+			expectedForm: map[string]string{
+				"grant_type":    "refresh_token",
+				"refresh_token": "offline-token",
+				"client_secret": "",
+			},
+		},
+		{
+			name: "When refresh_token is missing",
+			secret: idManagerSecret{
+				authMethod: AuthMethodRefreshToken,
+				client_id:  "0001",
+			},
+			expectedErr:    true,
+			expectedErrMsg: "refresh_token is required",
+		},
+		{
+			name: "When auth method is private_key_jwt",
+			secret: idManagerSecret{
+				authMethod:   AuthMethodPrivateKeyJWT,
+				client_id:    "0001",
+				privateKey:   []byte(privateKeyPem),
+				privateKeyID: "test-key",
+			},
+			expectedType: &privateKeyJWTTokenSource{},
+			expectedForm: map[string]string{
+				"grant_type":            "client_credentials",
+				"client_assertion_type": clientAssertionType,
+				"client_secret":         "",
+			},
+		},
+		{
+			name: "When private_key is missing",
+			secret: idManagerSecret{
+				authMethod: AuthMethodPrivateKeyJWT,
+				client_id:  "0001",
+			},
+			expectedErr:    true,
+			expectedErrMsg: "private_key is required",
+		},
+		{
+			name: "When private_key is not PEM",
+			secret: idManagerSecret{
+				authMethod: AuthMethodPrivateKeyJWT,
+				client_id:  "0001",
+				privateKey: []byte("not-pem"),
+			},
+			expectedErr:    true,
+			expectedErrMsg: "failed to decode private_key as PEM",
+		},
+		{
+			name: "When auth method is tls_client_auth",
+			secret: idManagerSecret{
+				authMethod:           AuthMethodTLSClientAuth,
+				client_id:            "0001",
+				hasClientCertificate: true,
+			},
+			expectedType: &tlsClientAuthTokenSource{},
+			expectedForm: map[string]string{
+				"grant_type":    "client_credentials",
+				"client_id":     "0001",
+				"client_secret": "",
+			},
+		},
+		{
+			name: "When client certificate is missing in tls_client_auth",
+			secret: idManagerSecret{
+				authMethod: AuthMethodTLSClientAuth,
+				client_id:  "0001",
+			},
+			expectedErr:    true,
+			expectedErrMsg: "client_certificate is required",
+		},
+		{
+			name: "When auth method is unknown",
+			secret: idManagerSecret{
+				authMethod: "unknown",
+			},
+			expectedErr:    true,
+			expectedErrMsg: "unsupported auth method",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &CDIClient{Host: "https://localhost"}
+			tokenSource, err := newAuthTokenSource(client, tc.secret)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if reflect.TypeOf(tokenSource) != reflect.TypeOf(tc.expectedType) {
+					t.Errorf("unexpected token source, expected %T but got %T", tc.expectedType, tokenSource)
+				}
+				form, err := tokenSource.tokenRequest()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				for key, value := range tc.expectedForm {
+					if form.Get(key) != value {
+						t.Errorf("unexpected form value of %s, expected %q but got %q", key, value, form.Get(key))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshTokenSourceTokenRequest(t *testing.T) {
+	privateKeyPem, _, _ := config.TestClientKeys()
+	client := &CDIClient{Host: "https://localhost"}
+	secret := idManagerSecret{
+		realm:      "CDI_DRA_Test",
+		authMethod: AuthMethodPrivateKeyJWT,
+		client_id:  "0001",
+		privateKey: []byte(privateKeyPem),
+	}
+	authTokenSource, err := newAuthTokenSource(client, secret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The refresh token of a session is used with the client authentication of the auth method
+	tokenSource := &refreshTokenSource{
+		client:       client,
+		realm:        secret.realm,
+		refreshToken: "token2",
+		auth:         authTokenSource,
+	}
+	form, err := tokenSource.tokenRequest()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedForm := map[string]string{
+		"grant_type":            "refresh_token",
+		"refresh_token":         "token2",
+		"client_id":             "0001",
+		"client_assertion_type": clientAssertionType,
+	}
+	for key, value := range expectedForm {
+		if form.Get(key) != value {
+			t.Errorf("unexpected form value of %s, expected %q but got %q", key, value, form.Get(key))
+		}
+	}
+}
+
+func TestNewClientAssertion(t *testing.T) {
+	privateKeyPem, _, _ := config.TestClientKeys()
+	key, err := parsePrivateKey([]byte(privateKeyPem))
+	if err != nil {
+		t.Fatalf("failed to parse private key: %v", err)
+	}
+	assertion, err := newClientAssertion(key, "0001", "test-key", testTokenURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		t.Fatalf("unexpected client assertion form, got %s", assertion)
+	}
+
+	var header map[string]string
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		t.Fatalf("failed to unmarshal header: %v", err)
+	}
+	if header["alg"] != "RS256" || header["kid"] != "test-key" {
+		t.Errorf("unexpected header of client assertion: %v", header)
+	}
+
+	var claims map[string]any
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		t.Fatalf("failed to unmarshal claims: %v", err)
+	}
+	if claims["iss"] != "0001" || claims["sub"] != "0001" || claims["aud"] != testTokenURL {
+		t.Errorf("unexpected claims of client assertion: %v", claims)
+	}
+
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key.Public().(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("failed to verify signature of client assertion: %v", err)
+	}
+}
@@ -19,83 +19,234 @@ package client
 import (
 	"cdi_dra/pkg/config"
 	"cdi_dra/pkg/kube_utils"
+	"cdi_dra/pkg/logging"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/oauth2"
 )
 
 const (
 	CDIAPITimeOut = 60 * time.Second
+	// maxLoggedBodyLength limits the response body logged on failure
+	maxLoggedBodyLength = 1024
 )
 
 type CDIClient struct {
-	Host        string
-	TenantId    string
-	ClusterId   string
-	Client      *http.Client
-	TokenSource oauth2.TokenSource
+	// Scheme, Host and BasePath are parsed from CDI endpoint. https is used if Scheme is empty
+	Scheme   string
+	Host     string
+	BasePath string
+	// IMEndpoint, FMEndpoint and CMEndpoint are endpoints of each component. CDI endpoint is used if nil
+	IMEndpoint *url.URL
+	FMEndpoint *url.URL
+	CMEndpoint *url.URL
+	TenantId   string
+	ClusterId  string
+	SecretKey  string
+	AuthMethod string
+	// VerifyToken enables verification of IM tokens with JWKS, expecting TokenIssuer and TokenAudience
+	VerifyToken   bool
+	TokenIssuer   string
+	TokenAudience string
+	Credentials   CredentialsSource
+	// RequestIDHeader is the header to send the request ID in the context and to receive the one of CDI. Not sent if empty
+	RequestIDHeader string
+	Client          *http.Client
+	TokenSource     oauth2.TokenSource
+	// transportOptions are kept to rebuild the transport on rotation of certificates
+	transportOptions transportOptions
+	transport        *reloadableTransport
 }
 
 type RequestIDKey struct{}
 
+// DefaultRequestIDHeader is the header of request IDs correlating logs with CDI
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// Components of CDI, used as the prefix of the key of their CA, e.g. fm_certificate
+const (
+	componentIM = "im"
+	componentFM = "fm"
+	componentCM = "cm"
+)
+
 func BuildCDIClient(config *config.Config, kc *kube_utils.KubeControllers) (*CDIClient, error) {
-	secret, err := kc.GetSecret(secretKey)
+	endpoint, err := ParseEndpoint(config.CDIEndpoint, config.AllowInsecureHTTP)
 	if err != nil {
 		return nil, err
 	}
-	var cert []byte
-	if secret.Data != nil {
-		certificate := secret.Data["certificate"]
-		if len(certificate) < secretCertificateLength {
-			cert = secret.Data["certificate"]
-		} else {
-			return nil, fmt.Errorf("certificate length exceeds the limitation")
-		}
+	if endpoint.Scheme == "http" {
+		slog.Warn("connecting CDI with insecure http", "endpoint", endpoint.String())
+	}
+	credentials, err := NewCredentialsSource(config, kc)
+	if err != nil {
+		return nil, err
+	}
+	data, err := credentials.Data()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := newTransportOptions(config)
+	if err != nil {
+		return nil, err
 	}
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(cert)
 
-	tlsConfig := &tls.Config{
-		RootCAs: caCertPool,
+	client := &CDIClient{
+		Scheme:           endpoint.Scheme,
+		Host:             endpoint.Host,
+		BasePath:         endpoint.Path,
+		TenantId:         config.TenantID,
+		ClusterId:        config.ClusterID,
+		SecretKey:        config.SecretKey(),
+		AuthMethod:       config.AuthMethod,
+		VerifyToken:      config.VerifyIMToken,
+		TokenIssuer:      config.IMTokenIssuer,
+		TokenAudience:    config.IMTokenAudience,
+		Credentials:      credentials,
+		RequestIDHeader:  config.RequestIDHeader,
+		transportOptions: opts,
+	}
+	componentEndpoints := []struct {
+		component string
+		endpoint  string
+		into      **url.URL
+	}{
+		{componentIM, config.IMEndpoint, &client.IMEndpoint},
+		{componentFM, config.FMEndpoint, &client.FMEndpoint},
+		{componentCM, config.CMEndpoint, &client.CMEndpoint},
+	}
+	for _, ce := range componentEndpoints {
+		if len(ce.endpoint) == 0 {
+			continue
+		}
+		if *ce.into, err = ParseEndpoint(ce.endpoint, config.AllowInsecureHTTP); err != nil {
+			return nil, err
+		}
+		if (*ce.into).Scheme == "http" {
+			slog.Warn("connecting component with insecure http", "component", ce.component, "endpoint", (*ce.into).String())
+		}
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
+	defaultTransport, hostTransports, err := client.buildTransports(data)
+	if err != nil {
+		return nil, err
+	}
+	client.transport = &reloadableTransport{}
+	client.transport.store(defaultTransport, hostTransports)
+	client.Client = &http.Client{
+		// Every request is traced and carries the trace context to CDI
+		Transport: otelhttp.NewTransport(client.transport),
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
+	client.TokenSource = CachedIMTokenSource(client, config.TokenRefreshFraction)
+
+	return client, nil
+}
+
+// WatchCredentials follows rotation of the certificates and credentials without restart until ctx is done.
+func (c *CDIClient) WatchCredentials(ctx context.Context) error {
+	return c.Credentials.Watch(ctx, c.onCredentialsUpdate)
+}
+
+// onCredentialsUpdate rebuilds the transport when the certificates are rotated,
+// and drops the cached token when the credentials to ID manager are changed.
+// The credentials are nil before the secret is created or after it is deleted.
+func (c *CDIClient) onCredentialsUpdate(oldData, newData map[string][]byte) {
+	if newData == nil {
+		// The current TLS config is kept until the secret is recreated
+		slog.Warn("credentials secret is deleted", "secret", c.SecretKey)
+	} else if credentialsChanged(oldData, newData, tlsSecretKeys) {
+		defaultTransport, hostTransports, err := c.buildTransports(newData)
+		if err != nil {
+			slog.Error("failed to rebuild TLS config, keeping the current one", "secret", c.SecretKey, "error", err)
+		} else if c.transport != nil {
+			c.transport.store(defaultTransport, hostTransports)
+			slog.Info("TLS config is reloaded", "secret", c.SecretKey)
+		}
 	}
+	if credentialsChanged(oldData, newData, credentialSecretKeys) {
+		if invalidator, ok := c.TokenSource.(tokenInvalidator); ok {
+			invalidator.Invalidate()
+			slog.Info("cached token is dropped since credentials are changed", "secret", c.SecretKey)
+		}
+	}
+}
 
-	client := &CDIClient{
-		Host:      config.CDIEndpoint,
-		TenantId:  config.TenantID,
-		ClusterId: config.ClusterID,
-		Client:    httpClient,
+// buildTransports creates the transport verifying CDI with the certificate key, and the transports for the hosts
+// of the components whose CA is set in their own key, e.g. fm_certificate.
+// The TLS server name override is only for CDI endpoint, so the components on other hosts get their own transports without it.
+func (c *CDIClient) buildTransports(data map[string][]byte) (*http.Transport, map[string]*http.Transport, error) {
+	tlsConfig, err := buildTLSConfig(data, c.transportOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	hostTransports := make(map[string]*http.Transport)
+	for _, comp := range []string{componentIM, componentFM, componentCM} {
+		caKey := comp + "_certificate"
+		hasCA := len(data[caKey]) > 0
+		endpoint := c.componentEndpoint(comp)
+		if endpoint == nil {
+			if hasCA {
+				slog.Warn("CA of component is ignored since its endpoint is not set", "key", caKey)
+			}
+			continue
+		}
+		opts := c.transportOptions
+		if endpoint.Host != c.Host {
+			opts.serverName = ""
+		}
+		if !hasCA && opts.serverName == c.transportOptions.serverName {
+			// The transport of CDI endpoint is used as is
+			continue
+		}
+		componentData := data
+		if hasCA {
+			componentData = maps.Clone(data)
+			componentData["certificate"] = data[caKey]
+		}
+		componentTLSConfig, err := buildTLSConfig(componentData, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to build TLS config of %s: %w", caKey, err)
+		}
+		hostTransports[endpoint.Host] = newTransport(componentTLSConfig, opts)
 	}
+	return newTransport(tlsConfig, c.transportOptions), hostTransports, nil
+}
 
-	client.TokenSource = CachedIMTokenSource(client, kc)
+// componentEndpoint returns the endpoint of the component if it is set.
+func (c *CDIClient) componentEndpoint(comp string) *url.URL {
+	switch comp {
+	case componentIM:
+		return c.IMEndpoint
+	case componentFM:
+		return c.FMEndpoint
+	case componentCM:
+		return c.CMEndpoint
+	}
+	return nil
+}
 
-	return client, nil
+// endpoint returns the scheme, host and base path to connect the component, falling back to CDI endpoint.
+func (c *CDIClient) endpoint(comp string) (string, string, string) {
+	if endpoint := c.componentEndpoint(comp); endpoint != nil {
+		return endpoint.Scheme, endpoint.Host, endpoint.Path
+	}
+	return c.Scheme, c.Host, c.BasePath
 }
 
-func (c *CDIClient) GetIMToken(ctx context.Context, secret idManagerSecret) (*IMToken, error) {
+func (c *CDIClient) GetIMToken(ctx context.Context, realm string, form url.Values) (*IMToken, error) {
 	imToken := &IMToken{}
 	r := newRequest(http.MethodPost)
-	path := fmt.Sprintf("id_manager/realms/%s/protocol/openid-connect/token", secret.realm)
-
-	bodyStr := "client_id=%s&client_secret=%s&username=%s&password=%s&scope=openid&response=id_token token&grant_type=password"
-	data := fmt.Sprintf(bodyStr, secret.client_id, secret.client_secret, secret.username, secret.password)
-
-	req := r.setHost(c.Host).setPath(path).setBody(data).setHeader("Content-Type", "application/x-www-form-urlencoded")
+	req := r.setEndpoint(c.endpoint(componentIM)).setPath(imTokenPath(realm)).setBody(form.Encode()).setHeader("Content-Type", "application/x-www-form-urlencoded")
 
 	slog.Debug("connecting", "url", req.url().String())
 	httpReq, err := newHTTPRequest(req)
@@ -108,12 +259,69 @@ func (c *CDIClient) GetIMToken(ctx context.Context, secret idManagerSecret) (*IM
 	}
 	err = resp.into(imToken)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		// The response of ID manager is not logged since it may carry tokens
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID)
 		return nil, err
 	}
 	return imToken, nil
 }
 
+// RevokeIMToken revokes the refresh token of the session in ID manager.
+func (c *CDIClient) RevokeIMToken(ctx context.Context, realm string, form url.Values) error {
+	r := newRequest(http.MethodPost)
+	path := fmt.Sprintf("id_manager/realms/%s/protocol/openid-connect/revoke", realm)
+	req := r.setEndpoint(c.endpoint(componentIM)).setPath(path).setBody(form.Encode()).setHeader("Content-Type", "application/x-www-form-urlencoded")
+
+	slog.Debug("connecting", "url", req.url().String())
+	httpReq, err := newHTTPRequest(req)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, httpReq)
+	if err != nil {
+		return err
+	}
+	err = resp.successful()
+	if err != nil {
+		// The response of ID manager is not logged since it may carry tokens
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID)
+		return err
+	}
+	return nil
+}
+
+// RevokeToken revokes the session in ID manager started by the token source of the client, if any.
+func (c *CDIClient) RevokeToken(ctx context.Context) error {
+	if revoker, ok := c.TokenSource.(tokenRevoker); ok {
+		return revoker.Revoke(ctx)
+	}
+	return nil
+}
+
+// RunTokenRefresher renews the token of the client in background until ctx is done.
+func (c *CDIClient) RunTokenRefresher(ctx context.Context) {
+	if refresher, ok := c.TokenSource.(tokenRefresher); ok {
+		refresher.Run(ctx)
+	}
+}
+
+// TokenState returns the state of the token cached by the client.
+func (c *CDIClient) TokenState() TokenState {
+	if refresher, ok := c.TokenSource.(tokenRefresher); ok {
+		return refresher.State()
+	}
+	return TokenState{}
+}
+
+// imTokenURL returns the URL of the token endpoint of ID manager for the realm.
+func (c *CDIClient) imTokenURL(realm string) string {
+	return newRequest(http.MethodPost).setEndpoint(c.endpoint(componentIM)).setPath(imTokenPath(realm)).url().String()
+}
+
+func imTokenPath(realm string) string {
+	return fmt.Sprintf("id_manager/realms/%s/protocol/openid-connect/token", realm)
+}
+
 func (c *CDIClient) GetFMMachineList(ctx context.Context) (*FMMachineList, error) {
 	fmMachineList := &FMMachineList{}
 	r := newRequest(http.MethodGet)
@@ -121,24 +329,14 @@ func (c *CDIClient) GetFMMachineList(ctx context.Context) (*FMMachineList, error
 	query := map[string]string{
 		"tenant_uuid": c.TenantId,
 	}
-	token, err := c.TokenSource.Token()
-	if err != nil {
-		return nil, err
-	}
-	req := r.setHost(c.Host).setPath(path).setQuery(query).setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	slog.Debug("connecting", "url", req.url().String())
-	httpReq, err := newHTTPRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.do(ctx, httpReq)
+	req := r.setEndpoint(c.endpoint(componentFM)).setPath(path).setQuery(query)
+	resp, err := c.doWithToken(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	err = resp.into(fmMachineList)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID, "response", resp.loggedBody())
 		return nil, err
 	}
 	return fmMachineList, nil
@@ -162,24 +360,14 @@ func (c *CDIClient) GetFMAvailableReservedResources(ctx context.Context, muuid s
 		"res_type":    "gpu",
 		"condition":   string(jsonData),
 	}
-	token, err := c.TokenSource.Token()
-	if err != nil {
-		return nil, err
-	}
-	req := r.setHost(c.Host).setPath(path).setQuery(query).setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	slog.Debug("connecting", "url", req.url().String())
-	httpReq, err := newHTTPRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.do(ctx, httpReq)
+	req := r.setEndpoint(c.endpoint(componentFM)).setPath(path).setQuery(query)
+	resp, err := c.doWithToken(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	err = resp.into(fmAvailables)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID, "response", resp.loggedBody())
 		return nil, err
 	}
 	return fmAvailables, nil
@@ -189,24 +377,14 @@ func (c *CDIClient) GetCMNodeGroups(ctx context.Context) (*CMNodeGroups, error)
 	cmNodeGroups := &CMNodeGroups{}
 	r := newRequest(http.MethodGet)
 	path := "cluster_manager/cluster_autoscaler/v2/tenants/" + c.TenantId + "/clusters/" + c.ClusterId + "/nodegroups"
-	token, err := c.TokenSource.Token()
-	if err != nil {
-		return nil, err
-	}
-	req := r.setHost(c.Host).setPath(path).setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	slog.Debug("connecting", "url", req.url().String())
-	httpReq, err := newHTTPRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.do(ctx, httpReq)
+	req := r.setEndpoint(c.endpoint(componentCM)).setPath(path)
+	resp, err := c.doWithToken(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	err = resp.into(cmNodeGroups)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID, "response", resp.loggedBody())
 		return nil, err
 	}
 	return cmNodeGroups, nil
@@ -216,24 +394,14 @@ func (c *CDIClient) GetCMNodeGroupInfo(ctx context.Context, ng CMNodeGroup) (*CM
 	cmNodeGroupInfo := &CMNodeGroupInfo{}
 	r := newRequest(http.MethodGet)
 	path := "cluster_manager/cluster_autoscaler/v2/tenants/" + c.TenantId + "/clusters/" + c.ClusterId + "/nodegroups/" + ng.UUID
-	token, err := c.TokenSource.Token()
-	if err != nil {
-		return nil, err
-	}
-	req := r.setHost(c.Host).setPath(path).setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	slog.Debug("connecting", "url", req.url().String())
-	httpReq, err := newHTTPRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.do(ctx, httpReq)
+	req := r.setEndpoint(c.endpoint(componentCM)).setPath(path)
+	resp, err := c.doWithToken(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	err = resp.into(cmNodeGroupInfo)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID, "response", resp.loggedBody())
 		return nil, err
 	}
 	return cmNodeGroupInfo, nil
@@ -243,36 +411,68 @@ func (c *CDIClient) GetCMNodeDetails(ctx context.Context, muuid string) (*CMNode
 	cmNodeDetails := &CMNodeDetails{}
 	r := newRequest(http.MethodGet)
 	path := "cluster_manager/cluster_autoscaler/v3/tenants/" + c.TenantId + "/clusters/" + c.ClusterId + "/machines/" + muuid
-	token, err := c.TokenSource.Token()
-	if err != nil {
-		return nil, err
-	}
-	req := r.setHost(c.Host).setPath(path).setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
-
-	slog.Debug("connecting", "url", req.url().String())
-	httpReq, err := newHTTPRequest(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.do(ctx, httpReq)
+	req := r.setEndpoint(c.endpoint(componentCM)).setPath(path)
+	resp, err := c.doWithToken(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	err = resp.into(cmNodeDetails)
 	if err != nil {
-		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "response", string(resp.body))
+		slog.Error(err.Error(), "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx), "serverRequestID", resp.serverRequestID, "response", resp.loggedBody())
 		return nil, err
 	}
 	return cmNodeDetails, nil
 }
 
+// doWithToken sends the request with the IM token. When CDI rejects the token with 401 or 403,
+// e.g. it is revoked early in ID manager, the token is dropped and the request is retried once with a new token.
+func (c *CDIClient) doWithToken(ctx context.Context, req *Request) (*result, error) {
+	for retried := false; ; retried = true {
+		token, err := c.TokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+		req.setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+
+		slog.Debug("connecting", "url", req.url().String())
+		httpReq, err := newHTTPRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.do(ctx, httpReq)
+		if err != nil {
+			return nil, err
+		}
+		if !retried && (resp.statusCode == http.StatusUnauthorized || resp.statusCode == http.StatusForbidden) {
+			if rejecter, ok := c.TokenSource.(tokenRejecter); ok {
+				slog.Warn("token is rejected, retrying with a new token", "code", resp.statusCode, "requestID", GetRequestIdFromContext(ctx))
+				rejecter.Reject(token)
+				continue
+			}
+		}
+		return resp, nil
+	}
+}
+
 type result struct {
 	body       []byte
 	statusCode int
+	endpoint   string
+	requestID  string
+	// serverRequestID is the request ID returned by CDI
+	serverRequestID string
 }
 
 func (c *CDIClient) do(ctx context.Context, req *http.Request) (*result, error) {
-	var result result
+	endpoint := *req.URL
+	endpoint.RawQuery = ""
+	result := result{
+		endpoint:  endpoint.String(),
+		requestID: GetRequestIdFromContext(ctx),
+	}
+	if _, ok := ctx.Value(RequestIDKey{}).(string); ok && len(c.RequestIDHeader) > 0 {
+		req.Header.Set(c.RequestIDHeader, result.requestID)
+	}
 	ctx, cancel := context.WithTimeout(ctx, CDIAPITimeOut)
 	defer cancel()
 	req = req.WithContext(ctx)
@@ -282,6 +482,9 @@ func (c *CDIClient) do(ctx context.Context, req *http.Request) (*result, error)
 		return &result, err
 	}
 	defer resp.Body.Close()
+	if len(c.RequestIDHeader) > 0 {
+		result.serverRequestID = resp.Header.Get(c.RequestIDHeader)
+	}
 
 	if resp.Body != nil {
 		data, err := io.ReadAll(resp.Body)
@@ -295,13 +498,36 @@ func (c *CDIClient) do(ctx context.Context, req *http.Request) (*result, error)
 	return &result, nil
 }
 
-func (r *result) into(v any) error {
+// loggedBody returns the response body to log on failure, whose secrets are redacted and which is truncated to maxLoggedBodyLength.
+func (r *result) loggedBody() string {
+	body := logging.Redact(string(r.body))
+	if len(body) > maxLoggedBodyLength {
+		body = body[:maxLoggedBodyLength] + "...(truncated)"
+	}
+	return body
+}
+
+func (r *result) successful() error {
 	if r.statusCode < 200 || r.statusCode >= 300 {
 		res := &unsuccessfulResponse{}
 		if err := json.Unmarshal(r.body, res); err != nil || res.Detail.Message == "" {
 			res.Detail.Message = string(r.body)
 		}
-		return fmt.Errorf("received unsuccessful response: %s", res.Detail.Message)
+		return &APIError{
+			StatusCode:      r.statusCode,
+			Code:            res.Detail.Code,
+			Message:         logging.Redact(res.Detail.Message),
+			Endpoint:        r.endpoint,
+			RequestID:       r.requestID,
+			ServerRequestID: r.serverRequestID,
+		}
+	}
+	return nil
+}
+
+func (r *result) into(v any) error {
+	if err := r.successful(); err != nil {
+		return err
 	}
 	if err := json.Unmarshal(r.body, v); err != nil {
 		return fmt.Errorf("failed to read response data into %T: %v", v, err)
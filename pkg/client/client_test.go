@@ -17,19 +17,31 @@ limitations under the License.
 package client
 
 import (
+	"bytes"
 	"cdi_dra/pkg/config"
+	"cdi_dra/pkg/logging"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 )
 
@@ -59,8 +71,10 @@ func TestCDIClientGetIMToken(t *testing.T) {
 			password: "pass",
 			realm:    "CDI_DRA_Test",
 			expectedToken: &IMToken{
-				AccessToken: "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
-				ExpiresIn:   1,
+				AccessToken:      "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
+				ExpiresIn:        1,
+				RefreshToken:     "token2",
+				RefreshExpiresIn: 2,
 			},
 			expectedErr: false,
 		},
@@ -91,15 +105,16 @@ func TestCDIClientGetIMToken(t *testing.T) {
 			defer stopController()
 			defer server.Close()
 
-			idManagerSecret := idManagerSecret{
-				username:      "user",
-				password:      tc.password,
-				realm:         tc.realm,
-				client_id:     "0001",
-				client_secret: "secret",
-			}
+			form := url.Values{}
+			form.Set("grant_type", "password")
+			form.Set("client_id", "0001")
+			form.Set("client_secret", "secret")
+			form.Set("username", "user")
+			form.Set("password", tc.password)
+			form.Set("scope", "openid")
+			form.Set("response", "id_token token")
 			ctx := context.WithValue(context.Background(), RequestIDKey{}, "test")
-			imToken, err := client.GetIMToken(ctx, idManagerSecret)
+			imToken, err := client.GetIMToken(ctx, tc.realm, form)
 			if tc.expectedErr {
 				if err == nil {
 					t.Errorf("expected error, but got none")
@@ -119,6 +134,60 @@ func TestCDIClientGetIMToken(t *testing.T) {
 	}
 }
 
+func TestCDIClientRevokeIMToken(t *testing.T) {
+	testCases := []struct {
+		name           string
+		refreshToken   string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:         "When refresh token is revoked as expected",
+			refreshToken: "token2",
+			expectedErr:  false,
+		},
+		{
+			name:           "When refresh token is unknown",
+			refreshToken:   "unknown-token",
+			expectedErr:    true,
+			expectedErrMsg: "received unsuccessful response: invalid token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  "00000000-0000-0001-0000-000000000000",
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			form := url.Values{}
+			form.Set("token", tc.refreshToken)
+			form.Set("token_type_hint", "refresh_token")
+			form.Set("client_id", "0001")
+			form.Set("client_secret", "secret")
+			ctx := context.WithValue(context.Background(), RequestIDKey{}, "test")
+			err := client.RevokeIMToken(ctx, "CDI_DRA_Test", form)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestCDIClientGetFMMachineList(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -724,6 +793,36 @@ func TestCDIClientDo(t *testing.T) {
 	}
 }
 
+func TestResultLoggedBody(t *testing.T) {
+	testCases := []struct {
+		name         string
+		resultBody   []byte
+		expectedBody string
+	}{
+		{
+			name:         "When body has a token",
+			resultBody:   []byte(`{"access_token":"token1"}`),
+			expectedBody: `{"access_token":"[REDACTED]"}`,
+		},
+		{
+			name:         "When body exceeds the limitation",
+			resultBody:   []byte(strings.Repeat("a", maxLoggedBodyLength+1)),
+			expectedBody: strings.Repeat("a", maxLoggedBodyLength) + "...(truncated)",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := &result{
+				body:       tc.resultBody,
+				statusCode: 500,
+			}
+			if body := result.loggedBody(); body != tc.expectedBody {
+				t.Errorf("unexpected logged body, expected %s but got %s", tc.expectedBody, body)
+			}
+		})
+	}
+}
+
 func TestResultInto(t *testing.T) {
 	resultBody1, _ := json.Marshal(testMachineList1)
 	unSccressResponse := unsuccessfulResponse{
@@ -804,6 +903,426 @@ func TestResultInto(t *testing.T) {
 	}
 }
 
+func TestCDIClientOnCredentialsUpdate(t *testing.T) {
+	_, clientCertPem, clientKeyPem := config.TestClientKeys()
+	testCases := []struct {
+		name                  string
+		update                func(secret *corev1.Secret)
+		deleted               bool
+		created               bool
+		expectedTransportSwap bool
+		expectedTokenDropped  bool
+	}{
+		{
+			name: "When certificate is rotated",
+			update: func(secret *corev1.Secret) {
+				caData, _ := config.CreateTestCACertificate()
+				secret.Data["certificate"] = []byte(caData.CertPem)
+			},
+			expectedTransportSwap: true,
+			expectedTokenDropped:  false,
+		},
+		{
+			name: "When password is changed",
+			update: func(secret *corev1.Secret) {
+				secret.Data["password"] = []byte("new-pass")
+			},
+			expectedTransportSwap: false,
+			expectedTokenDropped:  true,
+		},
+		{
+			name: "When client certificate is rotated",
+			update: func(secret *corev1.Secret) {
+				secret.Data["client_certificate"] = []byte(clientCertPem)
+				secret.Data["client_key"] = []byte(clientKeyPem)
+			},
+			expectedTransportSwap: true,
+			expectedTokenDropped:  true,
+		},
+		{
+			name: "When rotated client certificate is invalid",
+			update: func(secret *corev1.Secret) {
+				secret.Data["client_certificate"] = []byte("invalid-certificate")
+			},
+			expectedTransportSwap: false,
+			expectedTokenDropped:  true,
+		},
+		{
+			name: "When only unrelated data is changed",
+			update: func(secret *corev1.Secret) {
+				secret.Data["note"] = []byte("updated")
+			},
+			expectedTransportSwap: false,
+			expectedTokenDropped:  false,
+		},
+		{
+			name:                  "When secret is deleted",
+			deleted:               true,
+			expectedTransportSwap: false,
+			expectedTokenDropped:  true,
+		},
+		{
+			name:                  "When secret is recreated",
+			created:               true,
+			expectedTransportSwap: true,
+			expectedTokenDropped:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  "00000000-0000-0001-0000-000000000000",
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			cachedToken(t, client, true)
+			transport := client.transport.transport.Load()
+
+			oldSecret, err := clientSet.KubeControllers.GetSecret(client.SecretKey)
+			if err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			newSecret := oldSecret.DeepCopy()
+			if tc.update != nil {
+				tc.update(newSecret)
+			}
+			oldData, newData := oldSecret.Data, newSecret.Data
+			if tc.deleted {
+				newData = nil
+			}
+			if tc.created {
+				oldData = nil
+			}
+			client.onCredentialsUpdate(oldData, newData)
+
+			swapped := client.transport.transport.Load() != transport
+			if swapped != tc.expectedTransportSwap {
+				t.Errorf("unexpected transport swap, expected %t but got %t", tc.expectedTransportSwap, swapped)
+			}
+			dropped := client.TokenSource.(*cachedIMTokenSource).validToken(time.Now()) == nil
+			if dropped != tc.expectedTokenDropped {
+				t.Errorf("unexpected token drop, expected %t but got %t", tc.expectedTokenDropped, dropped)
+			}
+		})
+	}
+}
+
+func TestCDIClientReloadCertificate(t *testing.T) {
+	testSpec := config.TestSpec{
+		TenantID:  "00000000-0000-0001-0000-000000000000",
+		ClusterID: "00000000-0000-0000-0001-000000000000",
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	client := clientSet.CDIClient
+	defer stopController()
+	defer server.Close()
+
+	// Server certificate is issued by another CA after rotation
+	rotatedServer, rotatedCertPem := CreateTLSServer(t)
+	rotatedServer.StartTLS()
+	defer rotatedServer.Close()
+	parsedURL, err := url.Parse(rotatedServer.URL)
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	changeHost(client, parsedURL.Host)
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.WatchCredentials(watchCtx); err != nil {
+		t.Fatalf("failed to watch credentials: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), RequestIDKey{}, "test")
+	if _, err := client.GetFMMachineList(ctx); err == nil {
+		t.Fatal("expected certificate error before rotation, but got none")
+	}
+
+	secret, err := clientSet.KubeControllers.GetSecret(client.SecretKey)
+	if err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	secret.Data["certificate"] = []byte(rotatedCertPem)
+	if _, err := clientSet.KubeClient.CoreV1().Secrets(secret.Namespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err = client.GetFMMachineList(ctx); err == nil {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Errorf("unexpected error after rotation: %v", err)
+}
+
+func TestCDIClientEndpointBasePath(t *testing.T) {
+	testCases := []struct {
+		name           string
+		basePath       string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When base path of gateway is set",
+			basePath:    "/gateway",
+			expectedErr: false,
+		},
+		{
+			name:           "When base path of gateway is not set",
+			basePath:       "",
+			expectedErr:    true,
+			expectedErrMsg: "received unsuccessful response",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  "00000000-0000-0002-0000-000000000000",
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			// plain http gateway serving CDI API under /gateway
+			gateway := httptest.NewServer(http.StripPrefix("/gateway", http.HandlerFunc(handleRequests)))
+			defer gateway.Close()
+			endpoint, err := ParseEndpoint(gateway.URL+tc.basePath, true)
+			if err != nil {
+				t.Fatalf("failed to parse endpoint: %v", err)
+			}
+			client.Scheme = endpoint.Scheme
+			client.Host = endpoint.Host
+			client.BasePath = endpoint.Path
+
+			_, err = client.GetFMMachineList(context.Background())
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCDIClientComponentEndpoints(t *testing.T) {
+	testCases := []struct {
+		name           string
+		fmEndpointSet  bool
+		fmCertSet      bool
+		serverName     string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:          "When endpoint of FM is not set",
+			fmEndpointSet: false,
+			fmCertSet:     false,
+			expectedErr:   false,
+		},
+		{
+			name:          "When endpoint and CA of FM are set",
+			fmEndpointSet: true,
+			fmCertSet:     true,
+			expectedErr:   false,
+		},
+		{
+			name:           "When endpoint of FM is set without its CA",
+			fmEndpointSet:  true,
+			fmCertSet:      false,
+			expectedErr:    true,
+			expectedErrMsg: "certificate signed by unknown authority",
+		},
+		{
+			name:          "When server name of CDI is overridden",
+			fmEndpointSet: true,
+			fmCertSet:     true,
+			serverName:    "cdi.example.com",
+			expectedErr:   false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  "00000000-0000-0002-0000-000000000000",
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			// FM runs on another host with another CA
+			fmServer, fmCertPem := CreateTLSServer(t)
+			fmServer.StartTLS()
+			defer fmServer.Close()
+			if tc.fmEndpointSet {
+				endpoint, err := ParseEndpoint(fmServer.URL, false)
+				if err != nil {
+					t.Fatalf("failed to parse endpoint: %v", err)
+				}
+				client.FMEndpoint = endpoint
+			}
+			secret, err := clientSet.KubeControllers.GetSecret(client.SecretKey)
+			if err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			newData := maps.Clone(secret.Data)
+			if tc.fmCertSet {
+				newData["fm_certificate"] = []byte(fmCertPem)
+			}
+			if len(tc.serverName) > 0 {
+				client.transportOptions.serverName = tc.serverName
+				// CDI endpoint does not answer to the server name, so the token is not fetched from ID manager
+				client.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: testAccessToken, Expiry: time.Now().Add(time.Hour)})
+			}
+			// rebuild the transports as the certificates are rotated
+			client.onCredentialsUpdate(map[string][]byte{}, newData)
+
+			_, err = client.GetFMMachineList(context.Background())
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			// ID manager is connected with CDI endpoint
+			if scheme, host, _ := client.endpoint(componentIM); scheme != "https" || host != client.Host {
+				t.Errorf("unexpected endpoint of ID manager: %s://%s", scheme, host)
+			}
+		})
+	}
+}
+
+func TestCDIClientRetryRejectedToken(t *testing.T) {
+	testCases := []struct {
+		name           string
+		staticToken    bool
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When cached token is revoked early",
+			staticToken: false,
+			expectedErr: false,
+		},
+		{
+			name:           "When token source can not drop the rejected token",
+			staticToken:    true,
+			expectedErr:    true,
+			expectedErrMsg: "status=401",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  "00000000-0000-0001-0000-000000000000",
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			revoked := &oauth2.Token{AccessToken: "revoked", Expiry: time.Now().Add(time.Hour)}
+			if tc.staticToken {
+				client.TokenSource = oauth2.StaticTokenSource(revoked)
+			} else {
+				ts, ok := client.TokenSource.(*cachedIMTokenSource)
+				if !ok {
+					t.Fatalf("unexpected token source %T", client.TokenSource)
+				}
+				ts.mu.Lock()
+				ts.token = revoked
+				ts.mu.Unlock()
+			}
+
+			_, err := client.GetFMMachineList(context.Background())
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				token, err := client.TokenSource.Token()
+				if err != nil || token.AccessToken == revoked.AccessToken {
+					t.Errorf("expected new token, but got %v: %v", token, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCDIClientTraceContext(t *testing.T) {
+	prevProvider := otel.GetTracerProvider()
+	prevPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(prevProvider)
+	defer otel.SetTextMapPropagator(prevPropagator)
+
+	testSpec := config.TestSpec{
+		TenantID:  "00000000-0000-0001-0000-000000000000",
+		ClusterID: "00000000-0000-0000-0001-000000000000",
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	client := clientSet.CDIClient
+	defer stopController()
+	defer server.Close()
+	cachedToken(t, client, true)
+
+	// CDI records the trace context it receives
+	traceparents := make(chan string, 1)
+	cdiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case traceparents <- r.Header.Get("traceparent"):
+		default:
+		}
+		handleRequests(w, r)
+	}))
+	defer cdiServer.Close()
+	endpoint, err := ParseEndpoint(cdiServer.URL, true)
+	if err != nil {
+		t.Fatalf("failed to parse endpoint: %v", err)
+	}
+	client.Scheme = endpoint.Scheme
+	client.Host = endpoint.Host
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "getMachineList")
+	defer span.End()
+	if _, err := client.GetFMMachineList(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	traceparent := <-traceparents
+	if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) {
+		t.Errorf("unexpected traceparent %q, expected trace ID %s", traceparent, span.SpanContext().TraceID())
+	}
+}
+
 func cachedToken(t *testing.T, client *CDIClient, tokenCached bool) {
 	if tokenCached {
 		// cached token
@@ -819,3 +1338,70 @@ func changeHost(client *CDIClient, host string) {
 		client.Host = host
 	}
 }
+
+func TestCDIClientRedactLogs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.NewLogger(&buf, logging.Options{Level: slog.LevelDebug})
+	if err != nil {
+		t.Fatalf("failed to build logger: %v", err)
+	}
+	prevLogger := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(prevLogger)
+
+	testSpec := config.TestSpec{
+		TenantID:  "00000000-0000-0001-0000-000000000000",
+		ClusterID: "00000000-0000-0000-0001-000000000000",
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	client := clientSet.CDIClient
+	defer stopController()
+	defer server.Close()
+
+	// CDI rejects every request and echoes back what it received
+	echoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "Authorization: %s\n%s", r.Header.Get("Authorization"), body)
+	}))
+	defer echoServer.Close()
+	endpoint, err := ParseEndpoint(echoServer.URL, true)
+	if err != nil {
+		t.Fatalf("failed to parse endpoint: %v", err)
+	}
+	client.Scheme = endpoint.Scheme
+	client.Host = endpoint.Host
+
+	_, err = client.TokenSource.Token()
+	if err == nil {
+		t.Fatal("expected error, but got none")
+	}
+	secrets := []string{"password=pass", "client_secret=secret"}
+	for _, secret := range secrets {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("secret %q is in error: %v", secret, err)
+		}
+	}
+
+	cachedIMTokenSource, ok := client.TokenSource.(*cachedIMTokenSource)
+	if !ok {
+		t.Fatalf("unexpected token source type %T", client.TokenSource)
+	}
+	cachedIMTokenSource.token = &oauth2.Token{AccessToken: testAccessToken, Expiry: time.Now().Add(time.Hour)}
+	_, err = client.GetFMMachineList(context.Background())
+	if err == nil {
+		t.Fatal("expected error, but got none")
+	}
+	if strings.Contains(err.Error(), testAccessToken) {
+		t.Errorf("access token is in error: %v", err)
+	}
+
+	if buf.Len() == 0 {
+		t.Fatal("nothing is logged")
+	}
+	for _, secret := range append(secrets, testAccessToken) {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("secret %q is logged: %s", secret, buf.String())
+		}
+	}
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"cdi_dra/pkg/config"
+	"cdi_dra/pkg/kube_utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	CredentialsSourceSecret = "secret"
+	CredentialsSourceFile   = "file"
+	CredentialsSourceVault  = "vault"
+)
+
+var CredentialsSources = []string{
+	CredentialsSourceSecret,
+	CredentialsSourceFile,
+	CredentialsSourceVault,
+}
+
+// vaultResponseLength limits the size of a response from Vault
+const vaultResponseLength = 100000 // 100 kB
+
+var tlsSecretKeys = []string{"certificate", "im_certificate", "fm_certificate", "cm_certificate", "client_certificate", "client_key"}
+
+var credentialSecretKeys = []string{
+	"username", "password", "realm", "client_id", "client_secret",
+	"auth_method", "refresh_token", "private_key", "private_key_id", "client_certificate", "client_key",
+}
+
+// credentialsKeys are all keys read from a credentials source, in the same form as the Secret
+var credentialsKeys = []string{
+	"certificate", "im_certificate", "fm_certificate", "cm_certificate", "client_certificate", "client_key",
+	"username", "password", "realm", "client_id", "client_secret",
+	"auth_method", "refresh_token", "private_key", "private_key_id",
+}
+
+// CredentialsSource provides the credentials and certificates to connect CDI, keyed as in the Secret.
+type CredentialsSource interface {
+	// Data returns the current credentials.
+	Data() (map[string][]byte, error)
+	// Watch calls handler with the old and new credentials whenever they change, until ctx is done.
+	Watch(ctx context.Context, handler func(oldData, newData map[string][]byte)) error
+}
+
+// NewCredentialsSource creates the credentials source selected in config.
+// In multi-tenant mode, the Secret name of the tenant is appended to the directory or the Vault path.
+func NewCredentialsSource(config *config.Config, kc *kube_utils.KubeControllers) (CredentialsSource, error) {
+	switch config.CredentialsSource {
+	case CredentialsSourceSecret, "":
+		return &secretCredentialsSource{
+			kubecontrollers: kc,
+			secretKey:       config.SecretKey(),
+		}, nil
+	case CredentialsSourceFile:
+		dir := config.CredentialsDir
+		if config.MultiTenant {
+			dir = filepath.Join(dir, config.SecretName)
+		}
+		return &fileCredentialsSource{
+			dir:      dir,
+			interval: config.CredentialsReloadInterval,
+		}, nil
+	case CredentialsSourceVault:
+		vaultPath := config.VaultPath
+		if config.MultiTenant {
+			vaultPath = path.Join(vaultPath, config.SecretName)
+		}
+		return &vaultCredentialsSource{
+			address:   strings.TrimSuffix(config.VaultAddress, "/"),
+			path:      strings.Trim(vaultPath, "/"),
+			tokenFile: config.VaultTokenFile,
+			client:    &http.Client{Timeout: CDIAPITimeOut},
+			interval:  config.CredentialsReloadInterval,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credentials source: %s", config.CredentialsSource)
+	}
+}
+
+// secretCredentialsSource reads credentials from the Secret through the informer.
+type secretCredentialsSource struct {
+	kubecontrollers *kube_utils.KubeControllers
+	secretKey       string
+}
+
+func (s *secretCredentialsSource) Data() (map[string][]byte, error) {
+	secret, err := s.kubecontrollers.GetSecret(s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return secretData(secret), nil
+}
+
+// Watch passes nil for the data of a secret which is created or deleted.
+func (s *secretCredentialsSource) Watch(ctx context.Context, handler func(oldData, newData map[string][]byte)) error {
+	return s.kubecontrollers.AddSecretHandler(s.secretKey, func(oldSecret, newSecret *corev1.Secret) {
+		// The informer keeps the handler registered, so changes after ctx is done are ignored
+		if ctx.Err() != nil {
+			return
+		}
+		handler(secretData(oldSecret), secretData(newSecret))
+	})
+}
+
+func secretData(secret *corev1.Secret) map[string][]byte {
+	if secret == nil {
+		return nil
+	}
+	return secret.Data
+}
+
+// fileCredentialsSource reads credentials from files named by their keys in a directory,
+// e.g. a projected volume of the Secret. The files are polled at every interval.
+type fileCredentialsSource struct {
+	dir      string
+	interval time.Duration
+}
+
+func (s *fileCredentialsSource) Data() (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	for _, key := range credentialsKeys {
+		content, err := os.ReadFile(filepath.Join(s.dir, key))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials file %s: %w", key, err)
+		}
+		data[key] = content
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no credentials found in %s", s.dir)
+	}
+	return data, nil
+}
+
+func (s *fileCredentialsSource) Watch(ctx context.Context, handler func(oldData, newData map[string][]byte)) error {
+	return pollCredentials(ctx, s.Data, s.interval, handler)
+}
+
+// vaultCredentialsSource reads credentials from a KV secret in a Vault compatible HTTP API.
+// Vault is only requested on the first read and at every poll, and the last data read successfully is served in between.
+type vaultCredentialsSource struct {
+	address string
+	// path is the path of the secret under /v1, e.g. secret/data/composable-dra for KV version 2
+	path string
+	// tokenFile is the file of the Vault token, e.g. written by Vault agent. VAULT_TOKEN is used if empty
+	tokenFile string
+	client    *http.Client
+	interval  time.Duration
+
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+func (s *vaultCredentialsSource) Data() (map[string][]byte, error) {
+	s.mu.Lock()
+	data := s.data
+	s.mu.Unlock()
+	if data != nil {
+		return data, nil
+	}
+	return s.fetch()
+}
+
+// fetch reads the credentials from Vault and caches them on success.
+func (s *vaultCredentialsSource) fetch() (map[string][]byte, error) {
+	token, err := s.token()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, s.address+"/v1/"+s.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials from vault: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, vaultResponseLength))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from vault: %w", err)
+	}
+
+	var vaultResp vaultResponse
+	if err := json.Unmarshal(body, &vaultResp); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to read response from vault: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received unsuccessful response from vault: %d %s", resp.StatusCode, strings.Join(vaultResp.Errors, ", "))
+	}
+
+	// KV version 2 nests the secret in data.data, while KV version 1 has it in data
+	var values map[string]any
+	var kv2 struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(vaultResp.Data, &kv2); err == nil && kv2.Data != nil {
+		values = kv2.Data
+	} else if err := json.Unmarshal(vaultResp.Data, &values); err != nil {
+		return nil, fmt.Errorf("failed to read secret data from vault: %w", err)
+	}
+	data := make(map[string][]byte)
+	for _, key := range credentialsKeys {
+		value, ok := values[key]
+		if !ok {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %s in vault must be a string", key)
+		}
+		data[key] = []byte(str)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no credentials found in vault path %s", s.path)
+	}
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	return data, nil
+}
+
+func (s *vaultCredentialsSource) token() (string, error) {
+	if len(s.tokenFile) > 0 {
+		token, err := os.ReadFile(s.tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read vault token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if len(token) == 0 {
+		return "", fmt.Errorf("vault token is not set")
+	}
+	return token, nil
+}
+
+func (s *vaultCredentialsSource) Watch(ctx context.Context, handler func(oldData, newData map[string][]byte)) error {
+	return pollCredentials(ctx, s.fetch, s.interval, handler)
+}
+
+// pollCredentials reads credentials at every interval in background and calls handler when they change.
+func pollCredentials(ctx context.Context, read func() (map[string][]byte, error), interval time.Duration, handler func(oldData, newData map[string][]byte)) error {
+	last, err := read()
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = config.DefaultCredentialsReloadInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			data, err := read()
+			if err != nil {
+				slog.Warn("failed to reload credentials", "error", err)
+				continue
+			}
+			if credentialsChanged(last, data, credentialsKeys) {
+				handler(last, data)
+				last = data
+			}
+		}
+	}()
+	return nil
+}
+
+func credentialsChanged(oldData, newData map[string][]byte, keys []string) bool {
+	for _, key := range keys {
+		if !bytes.Equal(oldData[key], newData[key]) {
+			return true
+		}
+	}
+	return false
+}
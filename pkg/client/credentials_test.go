@@ -0,0 +1,420 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	ku "cdi_dra/pkg/kube_utils"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewCredentialsSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         config.Config
+		expectedDir    string
+		expectedPath   string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name: "When credentials source is secret",
+			config: config.Config{
+				CredentialsSource: CredentialsSourceSecret,
+			},
+		},
+		{
+			name: "When credentials source is file",
+			config: config.Config{
+				CredentialsSource: CredentialsSourceFile,
+				CredentialsDir:    "/etc/composable-dra/credentials",
+			},
+			expectedDir: "/etc/composable-dra/credentials",
+		},
+		{
+			name: "When credentials source is file in multi-tenant mode",
+			config: config.Config{
+				CredentialsSource: CredentialsSourceFile,
+				CredentialsDir:    "/etc/composable-dra/credentials",
+				MultiTenant:       true,
+				SecretName:        "tenant-a-secret",
+			},
+			expectedDir: "/etc/composable-dra/credentials/tenant-a-secret",
+		},
+		{
+			name: "When credentials source is vault in multi-tenant mode",
+			config: config.Config{
+				CredentialsSource: CredentialsSourceVault,
+				VaultAddress:      "https://vault:8200/",
+				VaultPath:         "/secret/data/composable-dra/",
+				MultiTenant:       true,
+				SecretName:        "tenant-a-secret",
+			},
+			expectedPath: "secret/data/composable-dra/tenant-a-secret",
+		},
+		{
+			name: "When credentials source is unknown",
+			config: config.Config{
+				CredentialsSource: "unknown",
+			},
+			expectedErr:    true,
+			expectedErrMsg: "unsupported credentials source: unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source, err := NewCredentialsSource(&tc.config, nil)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch source := source.(type) {
+			case *fileCredentialsSource:
+				if source.dir != tc.expectedDir {
+					t.Errorf("unexpected dir, expected %s but got %s", tc.expectedDir, source.dir)
+				}
+			case *vaultCredentialsSource:
+				if source.path != tc.expectedPath {
+					t.Errorf("unexpected path, expected %s but got %s", tc.expectedPath, source.path)
+				}
+				if source.address != "https://vault:8200" {
+					t.Errorf("unexpected address: %s", source.address)
+				}
+			case *secretCredentialsSource:
+				if len(tc.expectedDir) > 0 || len(tc.expectedPath) > 0 {
+					t.Errorf("unexpected credentials source %T", source)
+				}
+			}
+		})
+	}
+}
+
+func TestFileCredentialsSourceData(t *testing.T) {
+	testCases := []struct {
+		name           string
+		files          map[string]string
+		expectedData   map[string]string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name: "When credentials files exist",
+			files: map[string]string{
+				"username":    "user",
+				"password":    "pass",
+				"certificate": "cert",
+				"unknown":     "ignored",
+			},
+			expectedData: map[string]string{
+				"username":    "user",
+				"password":    "pass",
+				"certificate": "cert",
+			},
+		},
+		{
+			name:           "When no credentials file exists",
+			files:          map[string]string{"unknown": "ignored"},
+			expectedErr:    true,
+			expectedErrMsg: "no credentials found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			}
+			source := &fileCredentialsSource{dir: dir}
+			data, err := source.Data()
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(data) != len(tc.expectedData) {
+					t.Errorf("unexpected data count, expected %d but got %d", len(tc.expectedData), len(data))
+				}
+				for key, value := range tc.expectedData {
+					if string(data[key]) != value {
+						t.Errorf("unexpected value of %s, expected %s but got %s", key, value, string(data[key]))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFileCredentialsSourceWatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("pass"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	source := &fileCredentialsSource{dir: dir, interval: 50 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan string, 1)
+	err := source.Watch(ctx, func(oldData, newData map[string][]byte) {
+		changed <- string(oldData["password"]) + "->" + string(newData["password"])
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "password"), []byte("new-pass"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	select {
+	case change := <-changed:
+		if change != "pass->new-pass" {
+			t.Errorf("unexpected change, expected pass->new-pass but got %s", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected credentials change, but got none")
+	}
+}
+
+func TestVaultCredentialsSourceData(t *testing.T) {
+	testCases := []struct {
+		name           string
+		path           string
+		token          string
+		expectedData   map[string]string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:  "When credentials are read from KV version 2",
+			path:  "secret/data/composable-dra",
+			token: "vault-token",
+			expectedData: map[string]string{
+				"username": "user",
+				"password": "pass",
+			},
+		},
+		{
+			name:  "When credentials are read from KV version 1",
+			path:  "kv/composable-dra",
+			token: "vault-token",
+			expectedData: map[string]string{
+				"username": "user",
+				"password": "pass",
+			},
+		},
+		{
+			name:           "When vault token is invalid",
+			path:           "secret/data/composable-dra",
+			token:          "invalid-token",
+			expectedErr:    true,
+			expectedErrMsg: "received unsuccessful response from vault: 403 permission denied",
+		},
+		{
+			name:           "When value is not a string",
+			path:           "secret/data/not-string",
+			token:          "vault-token",
+			expectedErr:    true,
+			expectedErrMsg: "value of password in vault must be a string",
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			writeResponse(w, http.StatusForbidden, map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/composable-dra":
+			writeResponse(w, http.StatusOK, map[string]any{
+				"data": map[string]any{
+					"data":     map[string]string{"username": "user", "password": "pass"},
+					"metadata": map[string]any{"version": 1},
+				},
+			})
+		case "/v1/kv/composable-dra":
+			writeResponse(w, http.StatusOK, map[string]any{
+				"data": map[string]string{"username": "user", "password": "pass"},
+			})
+		case "/v1/secret/data/not-string":
+			writeResponse(w, http.StatusOK, map[string]any{
+				"data": map[string]any{
+					"data": map[string]any{"password": 1234},
+				},
+			})
+		default:
+			writeResponse(w, http.StatusNotFound, map[string][]string{"errors": {}})
+		}
+	}))
+	defer server.Close()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenFile := filepath.Join(t.TempDir(), "token")
+			if err := os.WriteFile(tokenFile, []byte(tc.token+"\n"), 0600); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			source := &vaultCredentialsSource{
+				address:   server.URL,
+				path:      tc.path,
+				tokenFile: tokenFile,
+				client:    server.Client(),
+			}
+			data, err := source.Data()
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				for key, value := range tc.expectedData {
+					if string(data[key]) != value {
+						t.Errorf("unexpected value of %s, expected %s but got %s", key, value, string(data[key]))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVaultCredentialsSourceWatch(t *testing.T) {
+	var requests atomic.Int32
+	var password atomic.Value
+	password.Store("pass")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeResponse(w, http.StatusOK, map[string]any{
+			"data": map[string]string{"password": password.Load().(string)},
+		})
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	source := &vaultCredentialsSource{
+		address:  server.URL,
+		path:     "kv/composable-dra",
+		client:   server.Client(),
+		interval: 50 * time.Millisecond,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := source.Data(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected vault is requested once, but got %d requests", requests.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan string, 1)
+	err := source.Watch(ctx, func(oldData, newData map[string][]byte) {
+		changed <- string(oldData["password"]) + "->" + string(newData["password"])
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	password.Store("new-pass")
+	select {
+	case change := <-changed:
+		if change != "pass->new-pass" {
+			t.Errorf("unexpected change, expected pass->new-pass but got %s", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected credentials change, but got none")
+	}
+	data, err := source.Data()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data["password"]) != "new-pass" {
+		t.Errorf("expected data of the last poll, but got %s", string(data["password"]))
+	}
+}
+
+func TestSecretCredentialsSourceWatch(t *testing.T) {
+	testConfig := &config.TestConfig{
+		Secret: config.CreateSecret("", config.CaseSecretCorrect),
+	}
+	kubeclient, dynamicclient := ku.CreateTestClient(t, testConfig)
+	controllers, stop := ku.CreateTestKubeControllers(t, testConfig, kubeclient, dynamicclient)
+	defer stop()
+
+	source := &secretCredentialsSource{
+		kubecontrollers: controllers,
+		secretKey:       config.DefaultNamespace + "/" + config.DefaultSecretName,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan string, 2)
+	err := source.Watch(ctx, func(oldData, newData map[string][]byte) {
+		changed <- string(oldData["password"]) + "->" + string(newData["password"])
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Deletion is reported with nil data
+	if err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Delete(context.Background(), config.DefaultSecretName, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	select {
+	case change := <-changed:
+		if change != "pass->" {
+			t.Errorf("unexpected change, expected pass-> but got %s", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected credentials change, but got none")
+	}
+
+	// Changes after ctx is done are not reported
+	cancel()
+	if _, err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Create(context.Background(), config.CreateSecret("", config.CaseSecretCorrect), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create secret: %v", err)
+	}
+	select {
+	case change := <-changed:
+		t.Errorf("expected no credentials change after ctx is done, but got %s", change)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
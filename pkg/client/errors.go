@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// APIError is an unsuccessful response from CDI API.
+type APIError struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code and Message are the detail of the response. Message is the raw body if the detail is not found
+	Code    string
+	Message string
+	// Endpoint is the URL of the request without query
+	Endpoint  string
+	RequestID string
+	// ServerRequestID is the request ID returned by CDI in the request ID header
+	ServerRequestID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("received unsuccessful response: %s (status=%d, code=%s, endpoint=%s", e.Message, e.StatusCode, e.Code, e.Endpoint)
+	if len(e.ServerRequestID) > 0 && e.ServerRequestID != e.RequestID {
+		msg += ", serverRequestID=" + e.ServerRequestID
+	}
+	return msg + ")"
+}
+
+// Retryable reports whether the request may succeed later, i.e. the response is 429 or 5xx.
+func (e *APIError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// IsAPIErrorStatus reports whether err is an APIError with one of the status codes.
+func IsAPIErrorStatus(err error, statusCodes ...int) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return slices.Contains(statusCodes, apiErr.StatusCode)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResultSuccessful(t *testing.T) {
+	testCases := []struct {
+		name             string
+		result           result
+		expectedErr      bool
+		expectedAPIError *APIError
+		expectedRetry    bool
+	}{
+		{
+			name:        "When response is successful",
+			result:      result{statusCode: http.StatusOK, body: []byte("{}")},
+			expectedErr: false,
+		},
+		{
+			name: "When response has detail",
+			result: result{
+				statusCode: http.StatusNotFound,
+				body:       []byte(`{"status":"404","detail":{"code":"E00404","message":"machine not found"}}`),
+				endpoint:   "https://cdi.example.com/fabric_manager/api/v1/machines",
+				requestID:  "abcdef",
+			},
+			expectedErr: true,
+			expectedAPIError: &APIError{
+				StatusCode: http.StatusNotFound,
+				Code:       "E00404",
+				Message:    "machine not found",
+				Endpoint:   "https://cdi.example.com/fabric_manager/api/v1/machines",
+				RequestID:  "abcdef",
+			},
+			expectedRetry: false,
+		},
+		{
+			name:        "When response has no detail",
+			result:      result{statusCode: http.StatusServiceUnavailable, body: []byte("service unavailable")},
+			expectedErr: true,
+			expectedAPIError: &APIError{
+				StatusCode: http.StatusServiceUnavailable,
+				Message:    "service unavailable",
+			},
+			expectedRetry: true,
+		},
+		{
+			name:        "When too many requests are sent",
+			result:      result{statusCode: http.StatusTooManyRequests, body: []byte(`{"detail":{"message":"rate limited"}}`)},
+			expectedErr: true,
+			expectedAPIError: &APIError{
+				StatusCode: http.StatusTooManyRequests,
+				Message:    "rate limited",
+			},
+			expectedRetry: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.result.successful()
+			if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			// the error is wrapped by callers
+			err = fmt.Errorf("API failed: %w", err)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, but got %v", err)
+			}
+			if !reflect.DeepEqual(tc.expectedAPIError, apiErr) {
+				t.Errorf("unexpected APIError, expected %#v but got %#v", tc.expectedAPIError, apiErr)
+			}
+			if apiErr.Retryable() != tc.expectedRetry {
+				t.Errorf("unexpected retryable, expected %t but got %t", tc.expectedRetry, apiErr.Retryable())
+			}
+			if !IsAPIErrorStatus(err, tc.expectedAPIError.StatusCode) {
+				t.Errorf("expected status %d, but got %d", tc.expectedAPIError.StatusCode, apiErr.StatusCode)
+			}
+			if !strings.HasPrefix(apiErr.Error(), "received unsuccessful response: "+tc.expectedAPIError.Message) {
+				t.Errorf("unexpected error message: %s", apiErr.Error())
+			}
+		})
+	}
+}
+
+func TestCDIClientAPIError(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		requestIDHeader         string
+		expectedServerRequestID string
+	}{
+		{
+			name:                    "When request ID header is set",
+			requestIDHeader:         DefaultRequestIDHeader,
+			expectedServerRequestID: "server-test",
+		},
+		{
+			name:                    "When request ID header is not set",
+			requestIDHeader:         "",
+			expectedServerRequestID: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  tenantIDNotFound,
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			client.RequestIDHeader = tc.requestIDHeader
+			defer stopController()
+			defer server.Close()
+
+			ctx := context.WithValue(context.Background(), RequestIDKey{}, "test")
+			_, err := client.GetFMMachineList(ctx)
+			if err == nil {
+				t.Fatal("expected error, but got none")
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected APIError, but got %v", err)
+			}
+			if apiErr.StatusCode != http.StatusNotFound {
+				t.Errorf("unexpected status, expected %d but got %d", http.StatusNotFound, apiErr.StatusCode)
+			}
+			if apiErr.Endpoint != server.URL+"/fabric_manager/api/v1/machines" {
+				t.Errorf("unexpected endpoint: %s", apiErr.Endpoint)
+			}
+			if apiErr.RequestID != "test" {
+				t.Errorf("unexpected request ID: %s", apiErr.RequestID)
+			}
+			if apiErr.ServerRequestID != tc.expectedServerRequestID {
+				t.Errorf("unexpected server request ID, expected %s but got %s", tc.expectedServerRequestID, apiErr.ServerRequestID)
+			}
+			if len(tc.expectedServerRequestID) > 0 && !strings.Contains(err.Error(), "serverRequestID="+tc.expectedServerRequestID) {
+				t.Errorf("server request ID is not in error message: %s", err.Error())
+			}
+			if IsAPIErrorStatus(err, http.StatusUnauthorized, http.StatusForbidden) {
+				t.Errorf("unexpected status: %d", apiErr.StatusCode)
+			}
+		})
+	}
+}
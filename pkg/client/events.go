@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// fmEventsPath is the Server-Sent Events endpoint of FM. It is not part of the published FM API yet,
+// so it is called only when the fm-events flag is set.
+const fmEventsPath = "fabric_manager/api/v1/events"
+
+// FMEventReservedResourcesChanged is notified when devices are attached to or detached from a fabric
+const FMEventReservedResourcesChanged = "reserved-resources-changed"
+
+// maxErrorBodyLength limits the response read from a failed subscription
+const maxErrorBodyLength = 1 << 20
+
+// WatchFMEvents subscribes to the notifications of FM with Server-Sent Events and calls handle for each of them
+// until the stream is closed or ctx is done. The events after lastEventID are requested if it is not empty.
+// It returns the ID of the last event received, so that the caller can resume from it after reconnecting.
+func (c *CDIClient) WatchFMEvents(ctx context.Context, lastEventID string, handle func(FMEvent)) (string, error) {
+	token, err := c.TokenSource.Token()
+	if err != nil {
+		return lastEventID, err
+	}
+	r := newRequest(http.MethodGet)
+	req := r.setEndpoint(c.endpoint(componentFM)).setPath(fmEventsPath).setQuery(map[string]string{"tenant_uuid": c.TenantId})
+	req.setHeader("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req.setHeader("Accept", "text/event-stream")
+	if len(lastEventID) > 0 {
+		req.setHeader("Last-Event-ID", lastEventID)
+	}
+	httpReq, err := newHTTPRequest(req)
+	if err != nil {
+		return lastEventID, err
+	}
+
+	// The stream is kept open, so CDIAPITimeOut is not applied
+	resp, err := c.Client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		return lastEventID, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		res := &result{body: body, statusCode: resp.StatusCode, endpoint: req.url().String()}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			// A new token is used for the next subscription
+			if rejecter, ok := c.TokenSource.(tokenRejecter); ok {
+				rejecter.Reject(token)
+			}
+		}
+		if err := res.successful(); err != nil {
+			return lastEventID, err
+		}
+		return lastEventID, fmt.Errorf("unexpected status of FM event stream: %d", resp.StatusCode)
+	}
+	slog.Info("subscribed to FM events", "tenantID", c.TenantId, "lastEventID", lastEventID)
+	return readFMEvents(resp.Body, lastEventID, handle)
+}
+
+// readFMEvents parses the stream of Server-Sent Events until it is closed.
+// Comments and the fields other than event, data and id are ignored.
+func readFMEvents(r io.Reader, lastEventID string, handle func(FMEvent)) (string, error) {
+	scanner := bufio.NewScanner(r)
+	var eventType string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			// A blank line dispatches the event
+			if len(data) > 0 {
+				event := FMEvent{}
+				if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &event); err != nil {
+					slog.Warn("invalid FM event is ignored", "eventID", lastEventID, "error", err)
+				} else {
+					event.Type = eventType
+					event.ID = lastEventID
+					handle(event)
+				}
+			}
+			eventType = ""
+			data = nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			lastEventID = value
+		}
+	}
+	return lastEventID, scanner.Err()
+}
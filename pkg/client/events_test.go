@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/utils/ptr"
+)
+
+func TestCDIClientWatchFMEvents(t *testing.T) {
+	testCases := []struct {
+		name                string
+		tenantId            string
+		lastEventID         string
+		expectedErr         bool
+		expectedErrMsg      string
+		expectedEvents      []FMEvent
+		expectedLastEventID string
+	}{
+		{
+			name:     "When all events are received",
+			tenantId: "00000000-0000-0001-0000-000000000000",
+			expectedEvents: []FMEvent{
+				{Type: FMEventReservedResourcesChanged, ID: "1", FabricID: ptr.To(1), MachineUUID: "00000000-0000-0000-0000-000000000000"},
+				{Type: "machine-added", ID: "2", FabricID: ptr.To(2)},
+			},
+			expectedLastEventID: "3",
+		},
+		{
+			name:        "When events are resumed after the last event id",
+			tenantId:    "00000000-0000-0001-0000-000000000000",
+			lastEventID: "1",
+			expectedEvents: []FMEvent{
+				{Type: "machine-added", ID: "2", FabricID: ptr.To(2)},
+			},
+			expectedLastEventID: "3",
+		},
+		{
+			name:                "When subscription is rejected",
+			tenantId:            "00000000-0000-0009-0000-000000000000",
+			lastEventID:         "1",
+			expectedErr:         true,
+			expectedErrMsg:      "received unsuccessful response",
+			expectedLastEventID: "1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:  tc.tenantId,
+				ClusterID: "00000000-0000-0000-0001-000000000000",
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			client := clientSet.CDIClient
+			defer stopController()
+			defer server.Close()
+
+			var events []FMEvent
+			lastEventID, err := client.WatchFMEvents(context.Background(), tc.lastEventID, func(event FMEvent) {
+				events = append(events, event)
+			})
+			if tc.expectedErr {
+				if err == nil {
+					t.Error("expected error, but got none")
+				}
+				if err != nil && !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Errorf("unexpected events, expected %+v but got %+v", tc.expectedEvents, events)
+			}
+			if lastEventID != tc.expectedLastEventID {
+				t.Errorf("unexpected last event id, expected %q but got %q", tc.expectedLastEventID, lastEventID)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	// jwksCacheTime is how long the keys of a realm are cached
+	jwksCacheTime = 1 * time.Hour
+	// jwksMinRefreshInterval limits refetching the keys when an unknown key id is found
+	jwksMinRefreshInterval = 1 * time.Minute
+	// tokenLeeway is the allowed clock skew between CDI_DRA and ID manager
+	tokenLeeway = 30 * time.Second
+)
+
+// signatureAlgorithms are the algorithms accepted for IM access tokens.
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+}
+
+type oidcConfiguration struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+type realmKeys struct {
+	keys    jose.JSONWebKeySet
+	fetched time.Time
+}
+
+// tokenVerifier verifies the signature and claims of IM access tokens with the JWKS of the realm.
+type tokenVerifier struct {
+	cdiclient *CDIClient
+	// issuer is the expected iss claim
+	issuer string
+	// audience is the expected aud claim. The audience is not checked if empty
+	audience string
+
+	mu    sync.Mutex
+	realm map[string]*realmKeys
+}
+
+func newTokenVerifier(client *CDIClient, issuer string, audience string) *tokenVerifier {
+	return &tokenVerifier{
+		cdiclient: client,
+		issuer:    issuer,
+		audience:  audience,
+		realm:     make(map[string]*realmKeys),
+	}
+}
+
+// verify checks the signature of the access token issued in the realm and its iss, aud, exp and nbf claims.
+func (v *tokenVerifier) verify(ctx context.Context, realm string, token string, now time.Time) error {
+	if err := v.verifyToken(ctx, realm, token, now); err != nil {
+		return fmt.Errorf("failed to verify IM token: %w", err)
+	}
+	return nil
+}
+
+func (v *tokenVerifier) verifyToken(ctx context.Context, realm string, token string, now time.Time) error {
+	if len(v.issuer) == 0 {
+		return fmt.Errorf("expected issuer is not configured")
+	}
+	parsed, err := jwt.ParseSigned(token, signatureAlgorithms)
+	if err != nil {
+		return fmt.Errorf("token is not a signed JWT: %w", err)
+	}
+	header := parsed.Headers[0]
+	keys, err := v.getKeys(ctx, realm, header.KeyID, now)
+	if err != nil {
+		return err
+	}
+	key, err := signingKey(keys, header.KeyID, header.Algorithm)
+	if err != nil {
+		return err
+	}
+	var claims jwt.Claims
+	if err := parsed.Claims(key.Key, &claims); err != nil {
+		return fmt.Errorf("signature is invalid: %w", err)
+	}
+	return v.verifyClaims(claims, now)
+}
+
+func (v *tokenVerifier) verifyClaims(claims jwt.Claims, now time.Time) error {
+	if claims.Expiry == nil {
+		return fmt.Errorf("exp claim is missing")
+	}
+	expected := jwt.Expected{
+		Issuer: v.issuer,
+		Time:   now,
+	}
+	if len(v.audience) > 0 {
+		expected.AnyAudience = jwt.Audience{v.audience}
+	}
+	err := claims.ValidateWithLeeway(expected, tokenLeeway)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return fmt.Errorf("issuer %q is not the expected %q", claims.Issuer, v.issuer)
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return fmt.Errorf("audience %v does not contain the expected %q", []string(claims.Audience), v.audience)
+	case errors.Is(err, jwt.ErrExpired):
+		return fmt.Errorf("token expired at %s", claims.Expiry.Time().Format(time.RFC3339))
+	case errors.Is(err, jwt.ErrNotValidYet):
+		return fmt.Errorf("token is not valid before %s", claims.NotBefore.Time().Format(time.RFC3339))
+	default:
+		return err
+	}
+}
+
+// signingKey returns the key which may verify a signature in the algorithm, among the keys with the key id.
+// Keys published for another use or algorithm are never used.
+func signingKey(keys []jose.JSONWebKey, kid string, alg string) (*jose.JSONWebKey, error) {
+	for i := range keys {
+		key := &keys[i]
+		if len(key.Use) > 0 && key.Use != "sig" {
+			continue
+		}
+		if len(key.Algorithm) > 0 && key.Algorithm != alg {
+			continue
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("key %q is not for signature in %s", kid, alg)
+}
+
+// getKeys returns the public keys with the key id in the realm.
+// The keys are refetched when they are stale or the key id is unknown, e.g. after key rotation in ID manager.
+func (v *tokenVerifier) getKeys(ctx context.Context, realm string, kid string, now time.Time) ([]jose.JSONWebKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	rk, ok := v.realm[realm]
+	if ok {
+		keys := rk.keys.Key(kid)
+		stale := now.Sub(rk.fetched) > jwksCacheTime
+		if len(keys) > 0 && !stale {
+			return keys, nil
+		}
+		if len(keys) == 0 && !stale && now.Sub(rk.fetched) < jwksMinRefreshInterval {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+	}
+	rk, err := v.fetchKeys(ctx, realm, now)
+	if err != nil {
+		return nil, err
+	}
+	v.realm[realm] = rk
+	keys := rk.keys.Key(kid)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return keys, nil
+}
+
+func (v *tokenVerifier) fetchKeys(ctx context.Context, realm string, now time.Time) (*realmKeys, error) {
+	var oidcConfig oidcConfiguration
+	path := fmt.Sprintf("id_manager/realms/%s/.well-known/openid-configuration", realm)
+	scheme, host, basePath := v.cdiclient.endpoint(componentIM)
+	r := newRequest(http.MethodGet).setEndpoint(scheme, host, basePath).setPath(path)
+	if err := v.get(ctx, r.url().String(), &oidcConfig); err != nil {
+		return nil, fmt.Errorf("failed to get openid configuration: %w", err)
+	}
+	jwksURL, err := url.Parse(oidcConfig.JWKSURI)
+	// http is accepted only when the endpoint of ID manager itself is insecure http
+	if err != nil || (jwksURL.Scheme != "https" && (jwksURL.Scheme != "http" || scheme != "http")) {
+		return nil, fmt.Errorf("jwks_uri must be https URL: %q", oidcConfig.JWKSURI)
+	}
+
+	// Keys are decoded one by one, so that a key of unsupported type does not hide the others
+	var jwks struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := v.get(ctx, jwksURL.String(), &jwks); err != nil {
+		return nil, fmt.Errorf("failed to get JWKS: %w", err)
+	}
+	rk := &realmKeys{
+		fetched: now,
+	}
+	for _, rawKey := range jwks.Keys {
+		var key jose.JSONWebKey
+		if err := key.UnmarshalJSON(rawKey); err != nil {
+			slog.Warn("skipping key in JWKS", "error", err)
+			continue
+		}
+		if !key.Valid() || !key.IsPublic() {
+			slog.Warn("skipping key in JWKS", "kid", key.KeyID, "error", "not a public key")
+			continue
+		}
+		rk.keys.Keys = append(rk.keys.Keys, key)
+	}
+	slog.Debug("JWKS is fetched", "realm", realm, "keys", len(rk.keys.Keys))
+	return rk, nil
+}
+
+func (v *tokenVerifier) get(ctx context.Context, rawURL string, into any) error {
+	httpReq, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	slog.Debug("connecting", "url", rawURL)
+	resp, err := v.cdiclient.do(ctx, httpReq)
+	if err != nil {
+		return err
+	}
+	return resp.into(into)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+func TestTokenVerifierVerify(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name           string
+		claims         map[string]any
+		issuer         string
+		noIssuer       bool
+		audience       string
+		tamper         func(token string) string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When token is verified",
+			audience:    testAudience,
+			expectedErr: false,
+		},
+		{
+			name:           "When expected issuer is not configured",
+			noIssuer:       true,
+			expectedErr:    true,
+			expectedErrMsg: "expected issuer is not configured",
+		},
+		{
+			name:        "When audience is a string",
+			claims:      map[string]any{"aud": testAudience},
+			audience:    testAudience,
+			expectedErr: false,
+		},
+		{
+			name:           "When audience is not expected",
+			audience:       "other-client",
+			expectedErr:    true,
+			expectedErrMsg: "does not contain the expected \"other-client\"",
+		},
+		{
+			name:           "When issuer is not expected",
+			issuer:         "https://other-issuer",
+			expectedErr:    true,
+			expectedErrMsg: "is not the expected \"https://other-issuer\"",
+		},
+		{
+			name:           "When token is expired",
+			claims:         map[string]any{"exp": now.Add(-time.Hour).Unix()},
+			expectedErr:    true,
+			expectedErrMsg: "token expired at",
+		},
+		{
+			name:           "When token is not valid yet",
+			claims:         map[string]any{"nbf": now.Add(time.Hour).Unix()},
+			expectedErr:    true,
+			expectedErrMsg: "token is not valid before",
+		},
+		{
+			name:           "When exp claim is missing",
+			claims:         map[string]any{"exp": nil},
+			expectedErr:    true,
+			expectedErrMsg: "exp claim is missing",
+		},
+		{
+			name: "When algorithm does not match the key",
+			tamper: func(token string) string {
+				return signTestToken(jose.RS384, testSigningKeyID, map[string]any{"exp": 2069550000})
+			},
+			expectedErr:    true,
+			expectedErrMsg: "key \"test-signing-key\" is not for signature in RS384",
+		},
+		{
+			name: "When key is published for encryption",
+			tamper: func(token string) string {
+				return signTestToken(jose.RS256, testEncryptionKeyID, map[string]any{"exp": 2069550000})
+			},
+			expectedErr:    true,
+			expectedErrMsg: "key \"test-encryption-key\" is not for signature in RS256",
+		},
+		{
+			name: "When algorithm is not accepted",
+			tamper: func(token string) string {
+				parts := strings.Split(token, ".")
+				header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"test-signing-key"}`))
+				return header + "." + parts[1] + "."
+			},
+			expectedErr:    true,
+			expectedErrMsg: "token is not a signed JWT",
+		},
+		{
+			name:           "When token is not signed",
+			tamper:         func(token string) string { return testAccessToken },
+			expectedErr:    true,
+			expectedErrMsg: "token is not a signed JWT",
+		},
+		{
+			name: "When signature is tampered",
+			tamper: func(token string) string {
+				parts := strings.Split(token, ".")
+				return parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2]))
+			},
+			expectedErr:    true,
+			expectedErrMsg: "signature is invalid",
+		},
+		{
+			name: "When claims are tampered",
+			tamper: func(token string) string {
+				parts := strings.Split(token, ".")
+				forged := strings.Split(createSignedAccessToken(map[string]any{"exp": 2069550000}), ".")
+				return parts[0] + "." + forged[1] + "." + parts[2]
+			},
+			expectedErr:    true,
+			expectedErrMsg: "signature is invalid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:   "00000000-0000-0001-0000-000000000000",
+				ClusterID:  "00000000-0000-0000-0001-000000000000",
+				CaseSecret: config.CaseSecretSignedToken,
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			defer stopController()
+			defer server.Close()
+
+			claims := map[string]any{
+				"iss": testIssuer(clientSet.CDIClient.Host),
+				"aud": []string{testAudience},
+				"exp": now.Add(time.Hour).Unix(),
+			}
+			for key, value := range tc.claims {
+				if value == nil {
+					delete(claims, key)
+					continue
+				}
+				claims[key] = value
+			}
+			token := createSignedAccessToken(claims)
+			if tc.tamper != nil {
+				token = tc.tamper(token)
+			}
+
+			issuer := testIssuer(clientSet.CDIClient.Host)
+			if len(tc.issuer) > 0 {
+				issuer = tc.issuer
+			} else if tc.noIssuer {
+				issuer = ""
+			}
+			verifier := newTokenVerifier(clientSet.CDIClient, issuer, tc.audience)
+			err := verifier.verify(context.Background(), "Signed_Test", token, now)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTokenVerifierGetKey(t *testing.T) {
+	testSpec := config.TestSpec{
+		TenantID:   "00000000-0000-0001-0000-000000000000",
+		ClusterID:  "00000000-0000-0000-0001-000000000000",
+		CaseSecret: config.CaseSecretSignedToken,
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	defer stopController()
+	defer server.Close()
+
+	now := time.Now()
+	verifier := newTokenVerifier(clientSet.CDIClient, testIssuer(clientSet.CDIClient.Host), "")
+	if _, err := verifier.getKeys(context.Background(), "Signed_Test", testSigningKeyID, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fetched := verifier.realm["Signed_Test"].fetched
+
+	// Unknown key id does not refetch JWKS within the minimum interval
+	_, err := verifier.getKeys(context.Background(), "Signed_Test", "rotated-key", now.Add(time.Second))
+	if err == nil || !strings.Contains(err.Error(), "unknown key id") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !verifier.realm["Signed_Test"].fetched.Equal(fetched) {
+		t.Error("expected cached JWKS, but refetched")
+	}
+
+	// Stale JWKS is refetched
+	later := now.Add(jwksCacheTime + time.Second)
+	if _, err := verifier.getKeys(context.Background(), "Signed_Test", testSigningKeyID, later); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !verifier.realm["Signed_Test"].fetched.Equal(later) {
+		t.Error("expected refetched JWKS, but cached")
+	}
+}
+
+func TestIdManagerTokenSourceVerifyToken(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secretCase     int
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When signed token is verified",
+			secretCase:  config.CaseSecretSignedToken,
+			expectedErr: false,
+		},
+		{
+			name:           "When token is not signed",
+			secretCase:     config.CaseSecretCorrect,
+			expectedErr:    true,
+			expectedErrMsg: "failed to verify IM token",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:   "00000000-0000-0001-0000-000000000000",
+				ClusterID:  "00000000-0000-0000-0001-000000000000",
+				CaseSecret: tc.secretCase,
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			defer stopController()
+			defer server.Close()
+
+			imTokenSource := &idManagerTokenSource{
+				cdiclient: clientSet.CDIClient,
+				verifier:  newTokenVerifier(clientSet.CDIClient, testIssuer(clientSet.CDIClient.Host), testAudience),
+			}
+			token, err := imTokenSource.Token()
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !token.Expiry.Equal(time.Unix(2069550000, 0)) {
+					t.Errorf("unexpected expiry, got %v", token.Expiry)
+				}
+			}
+		})
+	}
+}
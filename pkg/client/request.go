@@ -18,20 +18,24 @@ package client
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"path"
+	"strings"
 )
 
 type Request struct {
-	method  string
-	scheme  string
-	host    string
-	path    string
-	query   url.Values
-	headers http.Header
-	body    io.Reader
+	method   string
+	scheme   string
+	host     string
+	basePath string
+	path     string
+	query    url.Values
+	headers  http.Header
+	body     io.Reader
 }
 
 func newRequest(method string) *Request {
@@ -41,8 +45,13 @@ func newRequest(method string) *Request {
 	}
 }
 
-func (r *Request) setHost(host string) *Request {
+// setEndpoint sets the scheme, host and base path of CDI endpoint. https is used if scheme is empty.
+func (r *Request) setEndpoint(scheme string, host string, basePath string) *Request {
+	if len(scheme) > 0 {
+		r.scheme = scheme
+	}
 	r.host = host
+	r.basePath = basePath
 	return r
 }
 
@@ -82,7 +91,9 @@ func (r *Request) url() *url.URL {
 	url.Scheme = r.scheme
 	url.Host = r.host
 
-	if len(r.path) != 0 {
+	if len(r.basePath) != 0 {
+		url.Path = strings.TrimSuffix(r.basePath, "/") + "/" + strings.TrimPrefix(r.path, "/")
+	} else if len(r.path) != 0 {
 		url.Path = r.path
 	}
 
@@ -100,3 +111,35 @@ func newHTTPRequest(req *Request) (*http.Request, error) {
 	httpReq.Header = req.headers
 	return httpReq, nil
 }
+
+// ParseEndpoint parses CDI endpoint as a URL with an optional base path, e.g. https://gateway.example.com/cdi.
+// http is accepted only if allowInsecure is true.
+func ParseEndpoint(endpoint string, allowInsecure bool) (*url.URL, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cdi endpoint: %w", err)
+	}
+	switch endpointURL.Scheme {
+	case "https":
+	case "http":
+		if !allowInsecure {
+			return nil, fmt.Errorf("cdi endpoint with http:// requires allow-insecure-http")
+		}
+	default:
+		return nil, fmt.Errorf("cdi endpoint format must be set starting https:// or http://")
+	}
+	if len(endpointURL.Host) == 0 {
+		return nil, fmt.Errorf("cdi endpoint must have a host")
+	}
+	if endpointURL.User != nil || len(endpointURL.RawQuery) > 0 || len(endpointURL.Fragment) > 0 {
+		return nil, fmt.Errorf("cdi endpoint must not have user info, query or fragment")
+	}
+	if len(endpointURL.Path) > 0 {
+		endpointURL.Path = path.Clean("/" + endpointURL.Path)
+		if endpointURL.Path == "/" {
+			endpointURL.Path = ""
+		}
+	}
+	endpointURL.RawPath = ""
+	return endpointURL, nil
+}
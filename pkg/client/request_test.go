@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	testCases := []struct {
+		name             string
+		endpoint         string
+		allowInsecure    bool
+		expectedScheme   string
+		expectedHost     string
+		expectedBasePath string
+		expectedErr      bool
+		expectedErrMsg   string
+	}{
+		{
+			name:           "When endpoint is host only",
+			endpoint:       "https://cdi.example.com",
+			expectedScheme: "https",
+			expectedHost:   "cdi.example.com",
+		},
+		{
+			name:             "When endpoint has base path",
+			endpoint:         "https://gateway.example.com:8443/lab/cdi/",
+			expectedScheme:   "https",
+			expectedHost:     "gateway.example.com:8443",
+			expectedBasePath: "/lab/cdi",
+		},
+		{
+			name:           "When endpoint has root path",
+			endpoint:       "https://cdi.example.com/",
+			expectedScheme: "https",
+			expectedHost:   "cdi.example.com",
+		},
+		{
+			name:             "When http is allowed",
+			endpoint:         "http://cdi.example.com/cdi",
+			allowInsecure:    true,
+			expectedScheme:   "http",
+			expectedHost:     "cdi.example.com",
+			expectedBasePath: "/cdi",
+		},
+		{
+			name:           "When http is not allowed",
+			endpoint:       "http://cdi.example.com",
+			expectedErr:    true,
+			expectedErrMsg: "cdi endpoint with http:// requires allow-insecure-http",
+		},
+		{
+			name:           "When scheme is missing",
+			endpoint:       "cdi.example.com",
+			expectedErr:    true,
+			expectedErrMsg: "cdi endpoint format must be set starting https:// or http://",
+		},
+		{
+			name:           "When host is missing",
+			endpoint:       "https:///cdi",
+			expectedErr:    true,
+			expectedErrMsg: "cdi endpoint must have a host",
+		},
+		{
+			name:           "When endpoint has query",
+			endpoint:       "https://cdi.example.com/cdi?key=value",
+			expectedErr:    true,
+			expectedErrMsg: "cdi endpoint must not have user info, query or fragment",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint, err := ParseEndpoint(tc.endpoint, tc.allowInsecure)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if endpoint.Scheme != tc.expectedScheme || endpoint.Host != tc.expectedHost || endpoint.Path != tc.expectedBasePath {
+				t.Errorf("unexpected endpoint, expected %s://%s%s but got %s://%s%s",
+					tc.expectedScheme, tc.expectedHost, tc.expectedBasePath, endpoint.Scheme, endpoint.Host, endpoint.Path)
+			}
+		})
+	}
+}
+
+func TestRequestURL(t *testing.T) {
+	testCases := []struct {
+		name        string
+		scheme      string
+		basePath    string
+		expectedURL string
+	}{
+		{
+			name:        "When scheme and base path are not set",
+			expectedURL: "https://cdi.example.com/fabric_manager/api/v1/machines",
+		},
+		{
+			name:        "When base path is set",
+			scheme:      "http",
+			basePath:    "/lab/cdi",
+			expectedURL: "http://cdi.example.com/lab/cdi/fabric_manager/api/v1/machines",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newRequest(http.MethodGet).setEndpoint(tc.scheme, "cdi.example.com", tc.basePath).setPath("fabric_manager/api/v1/machines")
+			if r.url().String() != tc.expectedURL {
+				t.Errorf("unexpected url, expected %s but got %s", tc.expectedURL, r.url().String())
+			}
+		})
+	}
+}
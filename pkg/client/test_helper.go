@@ -28,12 +28,10 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
-	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
-	"net/url"
 	"regexp"
 	"slices"
 	"strconv"
@@ -42,6 +40,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
 	v1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -65,6 +65,28 @@ const (
 
 var tenantIDs = []string{tenantID1, tenantID2, tenantID3, tenantID4}
 
+var testSecretRefreshToken = config.TestRefreshToken
+
+// testUnavailableRefreshToken makes the stub IM answer 503 to the refresh token grant
+const testUnavailableRefreshToken = "unavailable-token"
+
+const (
+	testSigningKeyID = "test-signing-key"
+	// testEncryptionKeyID is the id of the signing key published for encryption in JWKS of the stub IM
+	testEncryptionKeyID = "test-encryption-key"
+	testAudience        = "cdi-dra"
+)
+
+// testSigningKey returns the key which signs the access tokens of the stub IM.
+// It is created on the first call, so that only tests pay for it.
+var testSigningKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
 var testAccessToken string = "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`))
 
 type TestIMToken struct {
@@ -409,55 +431,117 @@ func createTestNodeDetails(nodeCount int) []CMNodeDetails {
 
 func handleRequests(w http.ResponseWriter, r *http.Request) {
 	var written bool
+	// CDI returns its own request ID
+	if requestID := r.Header.Get(DefaultRequestIDHeader); len(requestID) > 0 {
+		w.Header().Set(DefaultRequestIDHeader, "server-"+requestID)
+	}
 	if r.Method == "POST" {
-		body, _ := io.ReadAll(r.Body)
-		targetString := "client_id=0001&client_secret=secret&username=user&password=pass&scope=openid&response=id_token token&grant_type=password"
-		if string(body) == targetString {
-			if r.URL.Path == "/id_manager/realms/CDI_DRA_Test/protocol/openid-connect/token" {
-				written = writeResponse(w, http.StatusOK, testIMToken)
-			}
-			if r.URL.Path == "/id_manager/realms/Nil_Test/protocol/openid-connect/token" {
-				w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/id_manager/realms/CDI_DRA_Test/protocol/openid-connect/revoke" {
+			if isValidRevokeRequest(r) {
 				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("invalid token"))
 			}
-			if r.URL.Path == "/id_manager/realms/Time_Test/protocol/openid-connect/token" {
-				expiry := time.Now().Add(35 * time.Second)
-				timeTestIMToken := testIMToken
-				timeTestIMToken.AccessToken = "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, expiry.Unix())))
-				written = writeResponse(w, http.StatusOK, timeTestIMToken)
-			}
-			if r.URL.Path == "/id_manager/realms/InvalidToken_Test/protocol/openid-connect/token" {
-				invalidToken := testIMToken
-				invalidToken.AccessToken = "token1"
-				written = writeResponse(w, http.StatusOK, invalidToken)
-			}
-			if r.URL.Path == "/id_manager/realms/Decode_Test/protocol/openid-connect/token" {
-				failedDecodeToken := testIMToken
-				failedDecodeToken.AccessToken = "token1.abc$"
-				written = writeResponse(w, http.StatusOK, failedDecodeToken)
-			}
-			if r.URL.Path == "/id_manager/realms/NotJson_Test/protocol/openid-connect/token" {
-				notJsonToken := testIMToken
-				notJsonToken.AccessToken = "token1.not-json"
-				written = writeResponse(w, http.StatusOK, notJsonToken)
-			}
-			if !written {
-				unSuccess := unsuccessfulResponse{
-					Detail: responseDetail{
-						Message: "IM credentials is not found",
-					},
-				}
-				writeResponse(w, http.StatusNotFound, unSuccess)
-			}
-		} else {
+			return
+		}
+		if r.PostFormValue("refresh_token") == testUnavailableRefreshToken {
+			writeResponse(w, http.StatusServiceUnavailable, unsuccessfulResponse{
+				Detail: responseDetail{
+					Message: "IM is unavailable",
+				},
+			})
+			return
+		}
+		if !isValidTokenRequest(r) {
 			response := "certification error"
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte(response))
+			return
+		}
+		if r.URL.Path == "/id_manager/realms/CDI_DRA_Test/protocol/openid-connect/token" {
+			written = writeResponse(w, http.StatusOK, testIMToken)
+		}
+		if r.URL.Path == "/id_manager/realms/Nil_Test/protocol/openid-connect/token" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+		}
+		if r.URL.Path == "/id_manager/realms/Time_Test/protocol/openid-connect/token" {
+			expiry := time.Now().Add(35 * time.Second)
+			timeTestIMToken := testIMToken
+			timeTestIMToken.AccessToken = "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, expiry.Unix())))
+			written = writeResponse(w, http.StatusOK, timeTestIMToken)
+		}
+		if r.URL.Path == "/id_manager/realms/InvalidToken_Test/protocol/openid-connect/token" {
+			invalidToken := testIMToken
+			invalidToken.AccessToken = "token1"
+			written = writeResponse(w, http.StatusOK, invalidToken)
+		}
+		if r.URL.Path == "/id_manager/realms/Decode_Test/protocol/openid-connect/token" {
+			failedDecodeToken := testIMToken
+			failedDecodeToken.AccessToken = "token1.abc$"
+			written = writeResponse(w, http.StatusOK, failedDecodeToken)
+		}
+		if r.URL.Path == "/id_manager/realms/Signed_Test/protocol/openid-connect/token" {
+			signedToken := testIMToken
+			signedToken.AccessToken = createSignedAccessToken(map[string]any{
+				"iss": testIssuer(r.Host),
+				"aud": []string{testAudience, "account"},
+				"exp": 2069550000,
+				"nbf": time.Now().Add(-time.Minute).Unix(),
+			})
+			written = writeResponse(w, http.StatusOK, signedToken)
+		}
+		if r.URL.Path == "/id_manager/realms/NotJson_Test/protocol/openid-connect/token" {
+			notJsonToken := testIMToken
+			notJsonToken.AccessToken = "token1.not-json"
+			written = writeResponse(w, http.StatusOK, notJsonToken)
+		}
+		if !written {
+			unSuccess := unsuccessfulResponse{
+				Detail: responseDetail{
+					Message: "IM credentials is not found",
+				},
+			}
+			writeResponse(w, http.StatusNotFound, unSuccess)
 		}
 	}
 	if r.Method == "GET" {
+		if r.URL.Path == "/id_manager/realms/Signed_Test/.well-known/openid-configuration" {
+			writeResponse(w, http.StatusOK, oidcConfiguration{
+				JWKSURI: "https://" + r.Host + "/id_manager/realms/Signed_Test/protocol/openid-connect/certs",
+			})
+			return
+		}
+		if r.URL.Path == "/id_manager/realms/Signed_Test/protocol/openid-connect/certs" {
+			writeResponse(w, http.StatusOK, jose.JSONWebKeySet{
+				Keys: []jose.JSONWebKey{
+					{
+						Key:       testSigningKey().Public(),
+						KeyID:     testSigningKeyID,
+						Use:       "sig",
+						Algorithm: string(jose.RS256),
+					},
+					{
+						Key:       testSigningKey().Public(),
+						KeyID:     testEncryptionKeyID,
+						Use:       "enc",
+						Algorithm: string(jose.RSA_OAEP),
+					},
+				},
+			})
+			return
+		}
 		if r.Header.Get("Authorization") == fmt.Sprintf("Bearer %s", testAccessToken) {
+			if r.URL.Path == "/fabric_manager/api/v1/events" {
+				if slices.Contains(tenantIDs, r.URL.Query().Get("tenant_uuid")) {
+					writeFMEvents(w, r.Header.Get("Last-Event-ID"))
+				} else {
+					writeResponse(w, http.StatusNotFound, unsuccessfulResponse{Detail: responseDetail{Message: "FM events API is failed"}})
+				}
+				return
+			}
 			if strings.HasPrefix(r.URL.Path, "/fabric_manager/api/v1/machines") {
 				remainder := strings.TrimPrefix(r.URL.Path, "/fabric_manager/api/v1/machines")
 				if remainder == "" {
@@ -622,8 +706,75 @@ func handleRequests(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 			}
+		} else if strings.HasPrefix(r.URL.Path, "/fabric_manager/") || strings.HasPrefix(r.URL.Path, "/cluster_manager/") {
+			unSuccess := unsuccessfulResponse{
+				Detail: responseDetail{
+					Message: "invalid access token",
+				},
+			}
+			writeResponse(w, http.StatusUnauthorized, unSuccess)
+		}
+	}
+}
+
+// isValidTokenRequest checks the credentials in a token request for every grant type.
+func isValidTokenRequest(r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		return false
+	}
+	form := r.PostForm
+	if form.Get("client_id") != "0001" {
+		return false
+	}
+	switch form.Get("grant_type") {
+	case "password":
+		return form.Get("client_secret") == "secret" && form.Get("username") == "user" && form.Get("password") == "pass" &&
+			form.Get("scope") == "openid" && form.Get("response") == "id_token token"
+	case "refresh_token":
+		return form.Get("refresh_token") == testIMToken.RefreshToken || form.Get("refresh_token") == testSecretRefreshToken
+	case "client_credentials":
+		if form.Get("client_assertion_type") == clientAssertionType {
+			return len(form.Get("client_assertion")) > 0
 		}
+		if len(form.Get("client_secret")) == 0 {
+			// tls_client_auth is authenticated by the client certificate
+			return r.TLS != nil && len(r.TLS.PeerCertificates) > 0
+		}
+		return form.Get("client_secret") == "secret"
+	}
+	return false
+}
+
+func testIssuer(host string) string {
+	return "https://" + host + "/id_manager/realms/Signed_Test"
+}
+
+// createSignedAccessToken creates a JWT with the claims signed by the test signing key in RS256.
+func createSignedAccessToken(claims map[string]any) string {
+	return signTestToken(jose.RS256, testSigningKeyID, claims)
+}
+
+// signTestToken creates a JWT with the claims signed by the test signing key in the algorithm, with the key id in its header.
+func signTestToken(alg jose.SignatureAlgorithm, kid string, claims map[string]any) string {
+	opts := (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: testSigningKey()}, opts)
+	if err != nil {
+		panic(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		panic(err)
 	}
+	return token
+}
+
+// isValidRevokeRequest checks the credentials and the token in a revocation request.
+func isValidRevokeRequest(r *http.Request) bool {
+	if err := r.ParseForm(); err != nil {
+		return false
+	}
+	form := r.PostForm
+	return form.Get("client_id") == "0001" && form.Get("token") == testIMToken.RefreshToken && form.Get("token_type_hint") == "refresh_token"
 }
 
 func writeResponse(w http.ResponseWriter, status int, response interface{}) bool {
@@ -688,10 +839,40 @@ func CreateTLSServer(t testing.TB) (*httptest.Server, string) {
 	if err != nil {
 		t.Fatalf("failed to load server key pair: %v", err)
 	}
-	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequestClientCert,
+	}
 	return server, caCertData.CertPem
 }
 
+// testFMEvents are streamed by the stub FM in order of id, and then the stream is closed
+var testFMEvents = []struct {
+	id    string
+	event string
+	data  string
+}{
+	{id: "1", event: FMEventReservedResourcesChanged, data: `{"fabric_id": 1, "mach_uuid": "00000000-0000-0000-0000-000000000000"}`},
+	{id: "2", event: "machine-added", data: `{"fabric_id": 2}`},
+	{id: "3", event: FMEventReservedResourcesChanged, data: `not json`},
+}
+
+// writeFMEvents streams the events after lastEventID with Server-Sent Events.
+func writeFMEvents(w http.ResponseWriter, lastEventID string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": stub FM\n\n")
+	for _, event := range testFMEvents {
+		if len(lastEventID) > 0 && event.id <= lastEventID {
+			continue
+		}
+		fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.id, event.event, event.data)
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 type TestClientSet struct {
 	CDIClient       *CDIClient
 	KubeClient      *fakekube.Clientset
@@ -702,14 +883,12 @@ type TestClientSet struct {
 func BuildTestClientSet(t testing.TB, testSpec config.TestSpec) (TestClientSet, *httptest.Server, ku.TestControllerShutdownFunc) {
 	server, certPem := CreateTLSServer(t)
 	server.StartTLS()
-	parsedURL, err := url.Parse(server.URL)
-	if err != nil {
-		t.Fatalf("failed to parse URL: %v", err)
-	}
 	if len(testSpec.CertPem) > 0 {
 		certPem = testSpec.CertPem
 	}
 	secret := config.CreateSecret(certPem, testSpec.CaseSecret)
+	secret.Namespace = testSpec.NamespaceOrDefault()
+	secret.Name = testSpec.SecretNameOrDefault()
 	testConfig := &config.TestConfig{
 		Spec:   testSpec,
 		Secret: secret,
@@ -737,9 +916,12 @@ func BuildTestClientSet(t testing.TB, testSpec config.TestSpec) (TestClientSet,
 	}
 
 	config := &config.Config{
-		CDIEndpoint: parsedURL.Host,
-		TenantID:    tenantID,
-		ClusterID:   clusterID,
+		CDIEndpoint:   server.URL,
+		TenantID:      tenantID,
+		ClusterID:     clusterID,
+		Namespace:     testSpec.NamespaceOrDefault(),
+		ConfigMapName: config.DefaultConfigMapName,
+		SecretName:    testSpec.SecretNameOrDefault(),
 	}
 	cdiClient, err := BuildCDIClient(config, controllers)
 	if err != nil {
@@ -17,13 +17,13 @@ limitations under the License.
 package client
 
 import (
-	"cdi_dra/pkg/config"
-	"cdi_dra/pkg/kube_utils"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -32,9 +32,15 @@ import (
 )
 
 const (
-	secretKey               = "composable-dra/composable-dra-secret"
 	secretAccessInfoLength  = 1000  // 1 kB
 	secretCertificateLength = 10000 // 10 kB
+	secretKeyLength         = 10000 // 10 kB
+)
+
+const (
+	DefaultTokenRefreshFraction = 0.75
+	refreshBackoffInitial       = 1 * time.Second
+	refreshBackoffMax           = 1 * time.Minute
 )
 
 type cachedIMTokenSource struct {
@@ -42,6 +48,24 @@ type cachedIMTokenSource struct {
 	mu               sync.Mutex
 	marginTime       time.Duration
 	token            *oauth2.Token
+	// issued is the time when token was issued, used to calculate its lifetime
+	issued time.Time
+	// refreshFraction is the fraction of the token lifetime after which the token is renewed in background
+	refreshFraction float64
+	lastRefresh     time.Time
+	lastError       error
+	failures        int
+	// refreshMu serializes issuing new tokens between Token() and the background refresher
+	refreshMu sync.Mutex
+}
+
+// TokenState is the state of the token cached by a client, used for health checks.
+type TokenState struct {
+	Valid               bool
+	Expiry              time.Time
+	LastRefresh         time.Time
+	LastError           error
+	ConsecutiveFailures int
 }
 
 type accessToken struct {
@@ -49,37 +73,109 @@ type accessToken struct {
 }
 
 type idManagerSecret struct {
-	username      string
-	password      string
-	realm         string
-	client_id     string
-	client_secret string
+	username             string
+	password             string
+	realm                string
+	client_id            string
+	client_secret        string
+	authMethod           string
+	refreshToken         string
+	privateKey           []byte
+	privateKeyID         string
+	hasClientCertificate bool
+}
+
+type tokenRevoker interface {
+	Revoke(ctx context.Context) error
+}
+
+type tokenInvalidator interface {
+	Invalidate()
+}
+
+type tokenRejecter interface {
+	Reject(token *oauth2.Token)
+}
+
+type tokenRefresher interface {
+	Run(ctx context.Context)
+	State() TokenState
 }
 
-func CachedIMTokenSource(client *CDIClient, controllers *kube_utils.KubeControllers) oauth2.TokenSource {
+func CachedIMTokenSource(client *CDIClient, refreshFraction float64) oauth2.TokenSource {
+	if refreshFraction <= 0 || refreshFraction >= 1 {
+		refreshFraction = DefaultTokenRefreshFraction
+	}
+	imTokenSource := &idManagerTokenSource{
+		cdiclient:  client,
+		authMethod: client.AuthMethod,
+	}
+	if client.VerifyToken {
+		imTokenSource.verifier = newTokenVerifier(client, client.TokenIssuer, client.TokenAudience)
+	}
 	return &cachedIMTokenSource{
-		newIMTokenSource: &idManagerTokenSource{
-			cdiclient:       client,
-			kubecontrollers: controllers,
-		},
-		marginTime: 30 * time.Second,
+		newIMTokenSource: imTokenSource,
+		marginTime:       30 * time.Second,
+		refreshFraction:  refreshFraction,
 	}
 
 }
 
-func (ts *cachedIMTokenSource) Token() (*oauth2.Token, error) {
-	var token *oauth2.Token
-	now := time.Now()
+// Invalidate drops the cached token and the session so that the next token is issued with the current credentials.
+func (ts *cachedIMTokenSource) Invalidate() {
+	ts.mu.Lock()
+	ts.token = nil
+	ts.mu.Unlock()
+	if invalidator, ok := ts.newIMTokenSource.(tokenInvalidator); ok {
+		invalidator.Invalidate()
+	}
+}
+
+// Reject drops the token and the session if the token is still cached, after CDI rejected it.
+// A token already replaced by another caller is ignored, so that concurrent rejections issue a new token only once.
+func (ts *cachedIMTokenSource) Reject(token *oauth2.Token) {
+	ts.mu.Lock()
+	current := ts.token != nil && ts.token.AccessToken == token.AccessToken
+	if current {
+		ts.token = nil
+	}
+	ts.mu.Unlock()
+	if !current {
+		return
+	}
+	if invalidator, ok := ts.newIMTokenSource.(tokenInvalidator); ok {
+		invalidator.Invalidate()
+	}
+}
+
+// Revoke drops the cached token and revokes the session in ID manager.
+func (ts *cachedIMTokenSource) Revoke(ctx context.Context) error {
 	ts.mu.Lock()
-	token = ts.token
+	ts.token = nil
 	ts.mu.Unlock()
-	if token != nil && token.Expiry.Add(-ts.marginTime).After(now) {
+	if revoker, ok := ts.newIMTokenSource.(tokenRevoker); ok {
+		return revoker.Revoke(ctx)
+	}
+	return nil
+}
+
+func (ts *cachedIMTokenSource) Token() (*oauth2.Token, error) {
+	if token := ts.validToken(time.Now()); token != nil {
+		slog.Debug("Token executed: using cached token")
+		return token, nil
+	}
+	ts.refreshMu.Lock()
+	defer ts.refreshMu.Unlock()
+	// The token may be renewed by the background refresher while waiting
+	if token := ts.validToken(time.Now()); token != nil {
 		slog.Debug("Token executed: using cached token")
 		return token, nil
 	}
 	slog.Debug("Token executed: trying to issue new token")
-	token, err := ts.newIMTokenSource.Token()
+	token, err := ts.issue()
 	if err != nil {
+		ts.mu.Lock()
+		defer ts.mu.Unlock()
 		if ts.token == nil {
 			slog.Error("failed to issue new token")
 			return nil, err
@@ -87,36 +183,165 @@ func (ts *cachedIMTokenSource) Token() (*oauth2.Token, error) {
 		slog.Error("unable to rotate token", "error", err)
 		return ts.token, nil
 	}
+	return token, nil
+}
+
+// Run renews the token in background at refreshFraction of its lifetime until ctx is done.
+// On failure it retries with exponential backoff, while Token() keeps returning the cached token.
+func (ts *cachedIMTokenSource) Run(ctx context.Context) {
+	delay := ts.nextRefresh(time.Now())
+	backoff := refreshBackoffInitial
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		ts.refreshMu.Lock()
+		_, err := ts.issue()
+		ts.refreshMu.Unlock()
+		if err != nil {
+			slog.Warn("background token refresh failed", "error", err, "retryAfter", backoff)
+			delay = backoff
+			backoff = min(backoff*2, refreshBackoffMax)
+			continue
+		}
+		backoff = refreshBackoffInitial
+		delay = ts.nextRefresh(time.Now())
+	}
+}
+
+// State returns the state of the cached token.
+func (ts *cachedIMTokenSource) State() TokenState {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	state := TokenState{
+		LastRefresh:         ts.lastRefresh,
+		LastError:           ts.lastError,
+		ConsecutiveFailures: ts.failures,
+	}
+	if ts.token != nil {
+		state.Valid = ts.token.Expiry.After(time.Now())
+		state.Expiry = ts.token.Expiry
+	}
+	return state
+}
+
+// issue gets a new token from ID manager and caches it. The caller must hold refreshMu.
+func (ts *cachedIMTokenSource) issue() (*oauth2.Token, error) {
+	token, err := ts.newIMTokenSource.Token()
+	now := time.Now()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if err != nil {
+		ts.lastError = err
+		ts.failures++
+		return nil, err
+	}
 	slog.Info("new token is successfully issued")
 	ts.token = token
+	ts.issued = now
+	ts.lastRefresh = now
+	ts.lastError = nil
+	ts.failures = 0
 	return token, nil
 }
 
+func (ts *cachedIMTokenSource) validToken(now time.Time) *oauth2.Token {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token != nil && ts.token.Expiry.Add(-ts.marginTime).After(now) {
+		return ts.token
+	}
+	return nil
+}
+
+// nextRefresh returns the duration until the cached token should be renewed.
+// The token is renewed before it enters the margin where Token() issues a new token by itself.
+func (ts *cachedIMTokenSource) nextRefresh(now time.Time) time.Duration {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token == nil {
+		return 0
+	}
+	lifetime := ts.token.Expiry.Sub(ts.issued)
+	refreshAt := ts.issued.Add(time.Duration(float64(lifetime) * ts.refreshFraction))
+	if marginAt := ts.token.Expiry.Add(-ts.marginTime); marginAt.Before(refreshAt) {
+		refreshAt = marginAt
+	}
+	return max(refreshAt.Sub(now), 0)
+}
+
 type idManagerTokenSource struct {
-	cdiclient       *CDIClient
-	kubecontrollers *kube_utils.KubeControllers
+	cdiclient *CDIClient
+	// authMethod overrides auth_method in the secret if set
+	authMethod string
+	// verifier verifies the signature and claims of access tokens if set
+	verifier *tokenVerifier
+
+	mu sync.Mutex
+	// refreshToken is the latest refresh token issued by ID manager
+	refreshToken string
+	// refreshExpiry is the expiry of refreshToken, which is zero if it does not expire like an offline token
+	refreshExpiry time.Time
+	// refreshAuthMethod is the auth method with which refreshToken was issued
+	refreshAuthMethod string
+	// secretRefreshToken is the refresh token in the secret when refreshToken was issued
+	secretRefreshToken string
 }
 
 func (ts *idManagerTokenSource) Token() (*oauth2.Token, error) {
-	var token oauth2.Token
 	secret, err := ts.getIdManagerSecret()
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.WithValue(context.Background(), RequestIDKey{}, config.RandomString(6))
-	slog.Debug("trying API to get IM token", "requestID", GetRequestIdFromContext(ctx))
-	imToken, err := ts.cdiclient.GetIMToken(ctx, secret)
+	if len(ts.authMethod) > 0 {
+		secret.authMethod = ts.authMethod
+	}
+	authTokenSource, err := newAuthTokenSource(ts.cdiclient, secret)
+	if err != nil {
+		return nil, err
+	}
+	var tokenSource oauth2.TokenSource = authTokenSource
+	if refreshToken := ts.sessionRefreshToken(secret, time.Now()); len(refreshToken) > 0 {
+		tokenSource = &sessionTokenSource{
+			session: &refreshTokenSource{
+				client:       ts.cdiclient,
+				realm:        secret.realm,
+				refreshToken: refreshToken,
+				auth:         authTokenSource,
+			},
+			fallback: authTokenSource,
+			onReject: ts.clearSession,
+		}
+	}
+	token, err := tokenSource.Token()
 	if err != nil {
-		slog.Error("IM token API failed", "requestID", GetRequestIdFromContext(ctx))
 		return nil, err
 	}
-	slog.Debug("IM token API completed successfully", "requestID", GetRequestIdFromContext(ctx))
+	if ts.verifier != nil {
+		if err := ts.verifier.verify(context.Background(), secret.realm, token.AccessToken, time.Now()); err != nil {
+			slog.Error("IM token is not trusted", "error", err)
+			return nil, err
+		}
+	}
+	ts.storeSession(secret, token, time.Now())
+	return token, nil
+}
 
-	token.AccessToken = imToken.AccessToken
+// newOAuth2Token converts the IM token to oauth2.Token, taking its expiry from the claims of the access token.
+func newOAuth2Token(imToken *IMToken) (*oauth2.Token, error) {
+	token := oauth2.Token{
+		AccessToken:  imToken.AccessToken,
+		RefreshToken: imToken.RefreshToken,
+	}
 
 	parts := strings.Split(imToken.AccessToken, ".")
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid access token: %s", imToken.AccessToken)
+		// The token itself is never included in errors
+		return nil, fmt.Errorf("invalid access token: not in JWT format")
 	}
 
 	decodedBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
@@ -132,52 +357,163 @@ func (ts *idManagerTokenSource) Token() (*oauth2.Token, error) {
 
 	token.Expiry = time.Unix(result.Expiry, 0)
 
-	return &token, nil
+	return token.WithExtra(map[string]any{"refresh_expires_in": imToken.RefreshExpiresIn}), nil
+}
+
+// sessionRefreshToken returns the refresh token of the current session if it is still usable for the secret.
+func (ts *idManagerTokenSource) sessionRefreshToken(secret idManagerSecret, now time.Time) string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.refreshToken) == 0 {
+		return ""
+	}
+	// The session is discarded when the secret is updated to use another credential
+	if ts.refreshAuthMethod != secret.authMethod || ts.secretRefreshToken != secret.refreshToken {
+		return ""
+	}
+	if !ts.refreshExpiry.IsZero() && !now.Before(ts.refreshExpiry) {
+		return ""
+	}
+	return ts.refreshToken
+}
+
+func (ts *idManagerTokenSource) storeSession(secret idManagerSecret, token *oauth2.Token, now time.Time) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(token.RefreshToken) == 0 {
+		ts.refreshToken = ""
+		return
+	}
+	if token.RefreshToken == ts.refreshToken {
+		// ID manager keeps the refresh token as it is
+		return
+	}
+	ts.refreshToken = token.RefreshToken
+	ts.refreshAuthMethod = secret.authMethod
+	ts.secretRefreshToken = secret.refreshToken
+	ts.refreshExpiry = time.Time{}
+	if expiresIn, ok := token.Extra("refresh_expires_in").(int64); ok && expiresIn > 0 {
+		ts.refreshExpiry = now.Add(time.Duration(expiresIn) * time.Second)
+	}
+}
+
+func (ts *idManagerTokenSource) Invalidate() {
+	ts.clearSession()
+}
+
+func (ts *idManagerTokenSource) clearSession() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.refreshToken = ""
+	ts.refreshExpiry = time.Time{}
+	ts.refreshAuthMethod = ""
+	ts.secretRefreshToken = ""
+}
+
+// Revoke revokes the session in ID manager started by the token source.
+// The session of refresh_token auth method is not revoked because it belongs to the refresh token in the secret.
+func (ts *idManagerTokenSource) Revoke(ctx context.Context) error {
+	ts.mu.Lock()
+	refreshToken := ts.refreshToken
+	authMethod := ts.refreshAuthMethod
+	ts.mu.Unlock()
+	if len(refreshToken) == 0 || authMethod == AuthMethodRefreshToken {
+		return nil
+	}
+	secret, err := ts.getIdManagerSecret()
+	if err != nil {
+		return err
+	}
+	secret.authMethod = authMethod
+	authTokenSource, err := newAuthTokenSource(ts.cdiclient, secret)
+	if err != nil {
+		return err
+	}
+	form := url.Values{}
+	form.Set("token", refreshToken)
+	form.Set("token_type_hint", "refresh_token")
+	if err := authTokenSource.authenticate(form); err != nil {
+		return err
+	}
+	if err := ts.cdiclient.RevokeIMToken(ctx, secret.realm, form); err != nil {
+		return err
+	}
+	ts.clearSession()
+	slog.Info("IM token is successfully revoked")
+	return nil
 }
 
 func (ts *idManagerTokenSource) getIdManagerSecret() (idManagerSecret, error) {
 	var imSecret idManagerSecret
-	secret, err := ts.kubecontrollers.GetSecret(secretKey)
+	data, err := ts.cdiclient.Credentials.Data()
 	if err != nil {
 		return imSecret, err
 	}
-	if secret != nil {
-		if secret.Data != nil {
-			username := string(secret.Data["username"])
-			if len(username) < secretAccessInfoLength {
-				imSecret.username = username
-			} else {
-				return imSecret, fmt.Errorf("username length exceeds the limitation")
-			}
-
-			password := string(secret.Data["password"])
-			if len(password) < secretAccessInfoLength {
-				imSecret.password = password
-			} else {
-				return imSecret, fmt.Errorf("password length exceeds the limitation")
-			}
-
-			realm := string(secret.Data["realm"])
-			if len(realm) < secretAccessInfoLength {
-				imSecret.realm = realm
-			} else {
-				return imSecret, fmt.Errorf("realm length exceeds the limitation")
-			}
-
-			client_id := string(secret.Data["client_id"])
-			if len(client_id) < secretAccessInfoLength {
-				imSecret.client_id = client_id
-			} else {
-				return imSecret, fmt.Errorf("client_id length exceeds the limitation")
-			}
-
-			client_secret := string(secret.Data["client_secret"])
-			if len(client_secret) < secretAccessInfoLength {
-				imSecret.client_secret = client_secret
-			} else {
-				return imSecret, fmt.Errorf("client_secret length exceeds the limitation")
-			}
+	if data != nil {
+		username := string(data["username"])
+		if len(username) < secretAccessInfoLength {
+			imSecret.username = username
+		} else {
+			return imSecret, fmt.Errorf("username length exceeds the limitation")
+		}
+
+		password := string(data["password"])
+		if len(password) < secretAccessInfoLength {
+			imSecret.password = password
+		} else {
+			return imSecret, fmt.Errorf("password length exceeds the limitation")
 		}
+
+		realm := string(data["realm"])
+		if len(realm) < secretAccessInfoLength {
+			imSecret.realm = realm
+		} else {
+			return imSecret, fmt.Errorf("realm length exceeds the limitation")
+		}
+
+		client_id := string(data["client_id"])
+		if len(client_id) < secretAccessInfoLength {
+			imSecret.client_id = client_id
+		} else {
+			return imSecret, fmt.Errorf("client_id length exceeds the limitation")
+		}
+
+		client_secret := string(data["client_secret"])
+		if len(client_secret) < secretAccessInfoLength {
+			imSecret.client_secret = client_secret
+		} else {
+			return imSecret, fmt.Errorf("client_secret length exceeds the limitation")
+		}
+
+		authMethod := string(data["auth_method"])
+		if len(authMethod) == 0 || slices.Contains(AuthMethods, authMethod) {
+			imSecret.authMethod = authMethod
+		} else {
+			return imSecret, fmt.Errorf("unsupported auth method: %s", authMethod)
+		}
+
+		refreshToken := string(data["refresh_token"])
+		if len(refreshToken) < secretKeyLength {
+			imSecret.refreshToken = refreshToken
+		} else {
+			return imSecret, fmt.Errorf("refresh_token length exceeds the limitation")
+		}
+
+		privateKey := data["private_key"]
+		if len(privateKey) < secretKeyLength {
+			imSecret.privateKey = privateKey
+		} else {
+			return imSecret, fmt.Errorf("private_key length exceeds the limitation")
+		}
+
+		privateKeyID := string(data["private_key_id"])
+		if len(privateKeyID) < secretAccessInfoLength {
+			imSecret.privateKeyID = privateKeyID
+		} else {
+			return imSecret, fmt.Errorf("private_key_id length exceeds the limitation")
+		}
+
+		imSecret.hasClientCertificate = len(data["client_certificate"]) > 0
 	}
 	return imSecret, nil
 }
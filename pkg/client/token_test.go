@@ -19,11 +19,15 @@ package client
 import (
 	"cdi_dra/pkg/config"
 	ku "cdi_dra/pkg/kube_utils"
+	"context"
 	"encoding/base64"
+	"net/http"
 	"regexp"
 	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/oauth2"
 )
 
 func TestCachedIMTokenSourceToken(t *testing.T) {
@@ -63,7 +67,7 @@ func TestCachedIMTokenSourceToken(t *testing.T) {
 			defer stopController()
 			defer server.Close()
 
-			tokenSource := CachedIMTokenSource(clientSet.CDIClient, clientSet.KubeControllers)
+			tokenSource := CachedIMTokenSource(clientSet.CDIClient, DefaultTokenRefreshFraction)
 
 			now := time.Now()
 			token1, _ := tokenSource.Token()
@@ -102,6 +106,160 @@ func TestCachedIMTokenSourceToken(t *testing.T) {
 	}
 }
 
+func TestCachedIMTokenSourceRun(t *testing.T) {
+	testCases := []struct {
+		name                string
+		secretCase          int
+		expectedValid       bool
+		expectedErr         bool
+		expectedErrMsg      string
+		expectedAccessToken string
+	}{
+		{
+			name:                "When token is renewed in background",
+			secretCase:          config.CaseSecretCorrect,
+			expectedValid:       true,
+			expectedErr:         false,
+			expectedAccessToken: testAccessToken,
+		},
+		{
+			name:           "When background refresh fails",
+			secretCase:     config.CaseSecretInvalidAuthMethod,
+			expectedValid:  false,
+			expectedErr:    true,
+			expectedErrMsg: "unsupported auth method: unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:   "00000000-0000-0001-0000-000000000000",
+				ClusterID:  "00000000-0000-0000-0001-000000000000",
+				CaseSecret: tc.secretCase,
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			defer stopController()
+			defer server.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go clientSet.CDIClient.RunTokenRefresher(ctx)
+
+			var state TokenState
+			for i := 0; i < 50; i++ {
+				state = clientSet.CDIClient.TokenState()
+				if state.Valid || state.LastError != nil {
+					break
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			if state.Valid != tc.expectedValid {
+				t.Errorf("unexpected validity of token, expected %t but got %t", tc.expectedValid, state.Valid)
+			}
+			if tc.expectedErr {
+				if state.LastError == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(state.LastError.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, state.LastError.Error())
+				}
+				if state.ConsecutiveFailures == 0 {
+					t.Error("expected consecutive failures, but got 0")
+				}
+			} else if !tc.expectedErr {
+				if state.LastError != nil {
+					t.Errorf("unexpected error: %v", state.LastError)
+				}
+				token, err := clientSet.CDIClient.TokenSource.Token()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if token.AccessToken != tc.expectedAccessToken {
+					t.Errorf("unexpected AccessToken, expected %s but got %s", tc.expectedAccessToken, token.AccessToken)
+				}
+			}
+		})
+	}
+}
+
+func TestCachedIMTokenSourceNextRefresh(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name            string
+		token           *oauth2.Token
+		issued          time.Time
+		expectedRefresh time.Duration
+	}{
+		{
+			name:            "When token is not cached",
+			expectedRefresh: 0,
+		},
+		{
+			name:            "When token is renewed at fraction of lifetime",
+			token:           &oauth2.Token{Expiry: now.Add(300 * time.Second)},
+			issued:          now,
+			expectedRefresh: 225 * time.Second,
+		},
+		{
+			name:            "When fraction of lifetime is in margin",
+			token:           &oauth2.Token{Expiry: now.Add(60 * time.Second)},
+			issued:          now,
+			expectedRefresh: 30 * time.Second,
+		},
+		{
+			name:            "When token is already expired",
+			token:           &oauth2.Token{Expiry: now.Add(-time.Second)},
+			issued:          now.Add(-time.Minute),
+			expectedRefresh: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := &cachedIMTokenSource{
+				marginTime:      30 * time.Second,
+				refreshFraction: DefaultTokenRefreshFraction,
+				token:           tc.token,
+				issued:          tc.issued,
+			}
+			refresh := ts.nextRefresh(now)
+			if refresh != tc.expectedRefresh {
+				t.Errorf("unexpected refresh duration, expected %v but got %v", tc.expectedRefresh, refresh)
+			}
+		})
+	}
+}
+
+func TestCachedIMTokenSourceReject(t *testing.T) {
+	testCases := []struct {
+		name           string
+		rejected       *oauth2.Token
+		expectedCached bool
+	}{
+		{
+			name:           "When cached token is rejected",
+			rejected:       &oauth2.Token{AccessToken: "token1"},
+			expectedCached: false,
+		},
+		{
+			name:           "When token already replaced is rejected",
+			rejected:       &oauth2.Token{AccessToken: "token0"},
+			expectedCached: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := &cachedIMTokenSource{
+				marginTime: 30 * time.Second,
+				token:      &oauth2.Token{AccessToken: "token1", Expiry: time.Now().Add(time.Hour)},
+			}
+			ts.Reject(tc.rejected)
+			if cached := ts.token != nil; cached != tc.expectedCached {
+				t.Errorf("unexpected cached token, expected %t but got %t", tc.expectedCached, cached)
+			}
+		})
+	}
+}
+
 func TestIdManagerTokenSourceToken(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -150,6 +308,40 @@ func TestIdManagerTokenSourceToken(t *testing.T) {
 			expectedErr:    true,
 			expectedErrMsg: "failed to unmarshal JSON",
 		},
+		{
+			name:                "When IMToken is obtained by client credentials",
+			secretCase:          config.CaseSecretClientCredentials,
+			expectedErr:         false,
+			expectedAccessToken: "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
+			expectedExpiry:      time.Unix(2069550000, 0),
+		},
+		{
+			name:                "When IMToken is obtained by refresh token",
+			secretCase:          config.CaseSecretRefreshToken,
+			expectedErr:         false,
+			expectedAccessToken: "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
+			expectedExpiry:      time.Unix(2069550000, 0),
+		},
+		{
+			name:                "When IMToken is obtained by private key JWT",
+			secretCase:          config.CaseSecretPrivateKeyJWT,
+			expectedErr:         false,
+			expectedAccessToken: "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
+			expectedExpiry:      time.Unix(2069550000, 0),
+		},
+		{
+			name:                "When IMToken is obtained by TLS client certificate",
+			secretCase:          config.CaseSecretTLSClientAuth,
+			expectedErr:         false,
+			expectedAccessToken: "token1" + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"exp":2069550000}`)),
+			expectedExpiry:      time.Unix(2069550000, 0),
+		},
+		{
+			name:           "When auth method is not supported",
+			secretCase:     config.CaseSecretInvalidAuthMethod,
+			expectedErr:    true,
+			expectedErrMsg: "unsupported auth method: unknown",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -164,8 +356,7 @@ func TestIdManagerTokenSourceToken(t *testing.T) {
 			defer server.Close()
 
 			imTokenSource := &idManagerTokenSource{
-				cdiclient:       clientSet.CDIClient,
-				kubecontrollers: clientSet.KubeControllers,
+				cdiclient: clientSet.CDIClient,
 			}
 
 			token, err := imTokenSource.Token()
@@ -192,6 +383,175 @@ func TestIdManagerTokenSourceToken(t *testing.T) {
 	}
 }
 
+func TestIdManagerTokenSourceRotateRefreshToken(t *testing.T) {
+	testSpec := config.TestSpec{
+		TenantID:   "00000000-0000-0001-0000-000000000000",
+		ClusterID:  "00000000-0000-0000-0001-000000000000",
+		CaseSecret: config.CaseSecretRefreshToken,
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	defer stopController()
+	defer server.Close()
+
+	imTokenSource := &idManagerTokenSource{
+		cdiclient: clientSet.CDIClient,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := imTokenSource.Token(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if imTokenSource.refreshToken != testIMToken.RefreshToken {
+			t.Errorf("unexpected rotated refresh token, expected %s but got %s", testIMToken.RefreshToken, imTokenSource.refreshToken)
+		}
+		if imTokenSource.secretRefreshToken != config.TestRefreshToken {
+			t.Errorf("unexpected refresh token of secret, expected %s but got %s", config.TestRefreshToken, imTokenSource.secretRefreshToken)
+		}
+	}
+}
+
+func TestIdManagerTokenSourceSession(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		secretCase           int
+		presetRefreshToken   string
+		expectedRefreshToken string
+		expectedRevoked      bool
+	}{
+		{
+			name:                 "When session is started by password",
+			secretCase:           config.CaseSecretCorrect,
+			expectedRefreshToken: testIMToken.RefreshToken,
+			expectedRevoked:      true,
+		},
+		{
+			name:                 "When refresh token of session is rejected",
+			secretCase:           config.CaseSecretCorrect,
+			presetRefreshToken:   "revoked-token",
+			expectedRefreshToken: testIMToken.RefreshToken,
+			expectedRevoked:      true,
+		},
+		{
+			name:                 "When session belongs to refresh token in secret",
+			secretCase:           config.CaseSecretRefreshToken,
+			expectedRefreshToken: testIMToken.RefreshToken,
+			expectedRevoked:      false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				TenantID:   "00000000-0000-0001-0000-000000000000",
+				ClusterID:  "00000000-0000-0000-0001-000000000000",
+				CaseSecret: tc.secretCase,
+			}
+			clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+			defer stopController()
+			defer server.Close()
+
+			imTokenSource := &idManagerTokenSource{
+				cdiclient: clientSet.CDIClient,
+			}
+			if len(tc.presetRefreshToken) > 0 {
+				imTokenSource.refreshToken = tc.presetRefreshToken
+			}
+			if _, err := imTokenSource.Token(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if imTokenSource.refreshToken != tc.expectedRefreshToken {
+				t.Errorf("unexpected refresh token, expected %s but got %s", tc.expectedRefreshToken, imTokenSource.refreshToken)
+			}
+			if imTokenSource.refreshExpiry.IsZero() {
+				t.Error("expected expiry of refresh token, but got zero")
+			}
+
+			if err := imTokenSource.Revoke(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if revoked := len(imTokenSource.refreshToken) == 0; revoked != tc.expectedRevoked {
+				t.Errorf("unexpected revocation, expected %t but got %t", tc.expectedRevoked, revoked)
+			}
+		})
+	}
+}
+
+func TestIdManagerTokenSourceSessionUnavailable(t *testing.T) {
+	testSpec := config.TestSpec{
+		TenantID:   "00000000-0000-0001-0000-000000000000",
+		ClusterID:  "00000000-0000-0000-0001-000000000000",
+		CaseSecret: config.CaseSecretCorrect,
+	}
+	clientSet, server, stopController := BuildTestClientSet(t, testSpec)
+	defer stopController()
+	defer server.Close()
+
+	imTokenSource := &idManagerTokenSource{
+		cdiclient:    clientSet.CDIClient,
+		refreshToken: testUnavailableRefreshToken,
+	}
+	// The password in the secret is correct, so falling back to the password grant would succeed
+	_, err := imTokenSource.Token()
+	if !IsAPIErrorStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("expected error with status %d, but got %v", http.StatusServiceUnavailable, err)
+	}
+	if imTokenSource.refreshToken != testUnavailableRefreshToken {
+		t.Errorf("unexpected refresh token, expected %s but got %s", testUnavailableRefreshToken, imTokenSource.refreshToken)
+	}
+}
+
+func TestIdManagerTokenSourceSessionRefreshToken(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name                 string
+		secret               idManagerSecret
+		refreshExpiry        time.Time
+		refreshAuthMethod    string
+		secretRefreshToken   string
+		expectedRefreshToken string
+	}{
+		{
+			name:                 "When refresh token is not expired",
+			refreshExpiry:        now.Add(time.Minute),
+			expectedRefreshToken: "session-token",
+		},
+		{
+			name:                 "When refresh token does not expire",
+			expectedRefreshToken: "session-token",
+		},
+		{
+			name:                 "When refresh token is expired",
+			refreshExpiry:        now.Add(-time.Second),
+			expectedRefreshToken: "",
+		},
+		{
+			name:                 "When auth method is changed",
+			secret:               idManagerSecret{authMethod: AuthMethodClientCredentials},
+			refreshExpiry:        now.Add(time.Minute),
+			expectedRefreshToken: "",
+		},
+		{
+			name:                 "When refresh token in secret is replaced",
+			secret:               idManagerSecret{authMethod: AuthMethodRefreshToken, refreshToken: "new-offline-token"},
+			refreshAuthMethod:    AuthMethodRefreshToken,
+			secretRefreshToken:   "offline-token",
+			expectedRefreshToken: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imTokenSource := &idManagerTokenSource{
+				refreshToken:       "session-token",
+				refreshExpiry:      tc.refreshExpiry,
+				refreshAuthMethod:  tc.refreshAuthMethod,
+				secretRefreshToken: tc.secretRefreshToken,
+			}
+			refreshToken := imTokenSource.sessionRefreshToken(tc.secret, now)
+			if refreshToken != tc.expectedRefreshToken {
+				t.Errorf("unexpected refresh token, expected %q but got %q", tc.expectedRefreshToken, refreshToken)
+			}
+		})
+	}
+}
+
 func TestGetIdManagerSecret(t *testing.T) {
 	testCases := []struct {
 		name                 string
@@ -266,7 +626,12 @@ func TestGetIdManagerSecret(t *testing.T) {
 			defer stop()
 
 			imTokenSource := idManagerTokenSource{
-				kubecontrollers: controllers,
+				cdiclient: &CDIClient{
+					Credentials: &secretCredentialsSource{
+						kubecontrollers: controllers,
+						secretKey:       config.DefaultNamespace + "/" + config.DefaultSecretName,
+					},
+				},
 			}
 
 			imSecret, err := imTokenSource.getIdManagerSecret()
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultTLSMinVersion       = "1.2"
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultDialTimeout         = 30 * time.Second
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// transportOptions are the settings of TLS and connections to CDI.
+type transportOptions struct {
+	minVersion          uint16
+	cipherSuites        []uint16
+	serverName          string
+	proxyURL            *url.URL
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	dialTimeout         time.Duration
+	tlsHandshakeTimeout time.Duration
+}
+
+func newTransportOptions(config *config.Config) (transportOptions, error) {
+	opts := transportOptions{
+		serverName:          config.TLSServerName,
+		maxIdleConns:        config.MaxIdleConns,
+		maxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		idleConnTimeout:     config.IdleConnTimeout,
+		dialTimeout:         config.DialTimeout,
+		tlsHandshakeTimeout: config.TLSHandshakeTimeout,
+	}
+	minVersion := config.TLSMinVersion
+	if len(minVersion) == 0 {
+		minVersion = DefaultTLSMinVersion
+	}
+	version, err := ParseTLSVersion(minVersion)
+	if err != nil {
+		return opts, err
+	}
+	opts.minVersion = version
+	opts.cipherSuites, err = ParseCipherSuites(config.TLSCipherSuites)
+	if err != nil {
+		return opts, err
+	}
+	if len(config.ProxyURL) > 0 {
+		opts.proxyURL, err = ParseProxyURL(config.ProxyURL)
+		if err != nil {
+			return opts, err
+		}
+	}
+	if opts.maxIdleConns == 0 {
+		opts.maxIdleConns = DefaultMaxIdleConns
+	}
+	if opts.maxIdleConnsPerHost == 0 {
+		opts.maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if opts.idleConnTimeout == 0 {
+		opts.idleConnTimeout = DefaultIdleConnTimeout
+	}
+	if opts.dialTimeout == 0 {
+		opts.dialTimeout = DefaultDialTimeout
+	}
+	if opts.tlsHandshakeTimeout == 0 {
+		opts.tlsHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	return opts, nil
+}
+
+// ParseTLSVersion parses a TLS version such as 1.2 and 1.3.
+func ParseTLSVersion(version string) (uint16, error) {
+	v, ok := tlsVersions[version]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version: %s", version)
+	}
+	return v, nil
+}
+
+// ParseCipherSuites parses comma separated names of cipher suites. Only secure cipher suites are accepted.
+func ParseCipherSuites(names string) ([]uint16, error) {
+	if len(strings.TrimSpace(names)) == 0 {
+		return nil, nil
+	}
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+	var ids []uint16
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ParseProxyURL parses the URL of an HTTP(S) proxy.
+func ParseProxyURL(rawURL string) (*url.URL, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+		return nil, fmt.Errorf("proxy url format must be set starting http:// or https://")
+	}
+	if len(proxyURL.Host) == 0 {
+		return nil, fmt.Errorf("proxy url must have host")
+	}
+	return proxyURL, nil
+}
+
+func buildTLSConfig(data map[string][]byte, opts transportOptions) (*tls.Config, error) {
+	var cert []byte
+	var clientCerts []tls.Certificate
+	if data != nil {
+		certificate := data["certificate"]
+		if len(certificate) < secretCertificateLength {
+			cert = data["certificate"]
+		} else {
+			return nil, fmt.Errorf("certificate length exceeds the limitation")
+		}
+
+		// Client certificate for mutual TLS
+		clientCertificate := data["client_certificate"]
+		clientKey := data["client_key"]
+		if len(clientCertificate) > 0 {
+			if len(clientCertificate) >= secretCertificateLength {
+				return nil, fmt.Errorf("client_certificate length exceeds the limitation")
+			}
+			if len(clientKey) >= secretKeyLength {
+				return nil, fmt.Errorf("client_key length exceeds the limitation")
+			}
+			clientCert, err := tls.X509KeyPair(clientCertificate, clientKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			clientCerts = append(clientCerts, clientCert)
+		}
+	}
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(cert)
+
+	return &tls.Config{
+		RootCAs:      caCertPool,
+		Certificates: clientCerts,
+		MinVersion:   opts.minVersion,
+		CipherSuites: opts.cipherSuites,
+		ServerName:   opts.serverName,
+	}, nil
+}
+
+// newTransport creates a transport to CDI. No proxy is used unless it is set in opts,
+// so that HTTPS_PROXY in the environment of the driver does not reroute the connection to CDI.
+func newTransport(tlsConfig *tls.Config, opts transportOptions) *http.Transport {
+	var proxy func(*http.Request) (*url.URL, error)
+	if opts.proxyURL != nil {
+		proxy = http.ProxyURL(opts.proxyURL)
+	}
+	dialer := &net.Dialer{
+		Timeout:   opts.dialTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: opts.tlsHandshakeTimeout,
+		MaxIdleConns:        opts.maxIdleConns,
+		MaxIdleConnsPerHost: opts.maxIdleConnsPerHost,
+		IdleConnTimeout:     opts.idleConnTimeout,
+		ForceAttemptHTTP2:   true,
+	}
+}
+
+// reloadableTransport is a RoundTripper whose transport can be replaced while requests are in flight.
+// Requests to the hosts in hostTransports, i.e. components with their own CA, use those transports instead.
+type reloadableTransport struct {
+	transport      atomic.Pointer[http.Transport]
+	hostTransports atomic.Pointer[map[string]*http.Transport]
+}
+
+func (t *reloadableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if hostTransports := t.hostTransports.Load(); hostTransports != nil {
+		if transport, ok := (*hostTransports)[req.URL.Host]; ok {
+			return transport.RoundTrip(req)
+		}
+	}
+	return t.transport.Load().RoundTrip(req)
+}
+
+func (t *reloadableTransport) store(transport *http.Transport, hostTransports map[string]*http.Transport) {
+	if old := t.transport.Swap(transport); old != nil {
+		old.CloseIdleConnections()
+	}
+	if old := t.hostTransports.Swap(&hostTransports); old != nil {
+		for _, oldTransport := range *old {
+			oldTransport.CloseIdleConnections()
+		}
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"cdi_dra/pkg/config"
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTransportOptions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         config.Config
+		expectedOpts   transportOptions
+		expectedProxy  string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:   "When options are not set",
+			config: config.Config{},
+			expectedOpts: transportOptions{
+				minVersion:          tls.VersionTLS12,
+				maxIdleConns:        DefaultMaxIdleConns,
+				maxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+				idleConnTimeout:     DefaultIdleConnTimeout,
+				dialTimeout:         DefaultDialTimeout,
+				tlsHandshakeTimeout: DefaultTLSHandshakeTimeout,
+			},
+		},
+		{
+			name: "When all options are set",
+			config: config.Config{
+				TLSMinVersion:       "1.3",
+				TLSCipherSuites:     "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+				TLSServerName:       "cdi.example.com",
+				ProxyURL:            "http://proxy.example.com:3128",
+				MaxIdleConns:        5,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     10 * time.Second,
+				DialTimeout:         3 * time.Second,
+				TLSHandshakeTimeout: 4 * time.Second,
+			},
+			expectedOpts: transportOptions{
+				minVersion:          tls.VersionTLS13,
+				cipherSuites:        []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+				serverName:          "cdi.example.com",
+				maxIdleConns:        5,
+				maxIdleConnsPerHost: 2,
+				idleConnTimeout:     10 * time.Second,
+				dialTimeout:         3 * time.Second,
+				tlsHandshakeTimeout: 4 * time.Second,
+			},
+			expectedProxy: "http://proxy.example.com:3128",
+		},
+		{
+			name:           "When TLS version is unsupported",
+			config:         config.Config{TLSMinVersion: "1.1"},
+			expectedErr:    true,
+			expectedErrMsg: "unsupported TLS version: 1.1",
+		},
+		{
+			name:           "When cipher suite is insecure",
+			config:         config.Config{TLSCipherSuites: "TLS_RSA_WITH_RC4_128_SHA"},
+			expectedErr:    true,
+			expectedErrMsg: "unsupported cipher suite: TLS_RSA_WITH_RC4_128_SHA",
+		},
+		{
+			name:           "When proxy url has unsupported scheme",
+			config:         config.Config{ProxyURL: "socks5://proxy.example.com:1080"},
+			expectedErr:    true,
+			expectedErrMsg: "proxy url format must be set starting http:// or https://",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := newTransportOptions(&tc.config)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			proxy := ""
+			if opts.proxyURL != nil {
+				proxy = opts.proxyURL.String()
+			}
+			if proxy != tc.expectedProxy {
+				t.Errorf("unexpected proxy url, expected %s but got %s", tc.expectedProxy, proxy)
+			}
+			opts.proxyURL = nil
+			if opts.minVersion != tc.expectedOpts.minVersion || opts.serverName != tc.expectedOpts.serverName ||
+				opts.maxIdleConns != tc.expectedOpts.maxIdleConns || opts.maxIdleConnsPerHost != tc.expectedOpts.maxIdleConnsPerHost ||
+				opts.idleConnTimeout != tc.expectedOpts.idleConnTimeout || opts.dialTimeout != tc.expectedOpts.dialTimeout ||
+				opts.tlsHandshakeTimeout != tc.expectedOpts.tlsHandshakeTimeout {
+				t.Errorf("unexpected options, expected %+v but got %+v", tc.expectedOpts, opts)
+			}
+			if len(opts.cipherSuites) != len(tc.expectedOpts.cipherSuites) {
+				t.Fatalf("unexpected cipher suites, expected %v but got %v", tc.expectedOpts.cipherSuites, opts.cipherSuites)
+			}
+			for i := range opts.cipherSuites {
+				if opts.cipherSuites[i] != tc.expectedOpts.cipherSuites[i] {
+					t.Errorf("unexpected cipher suites, expected %v but got %v", tc.expectedOpts.cipherSuites, opts.cipherSuites)
+				}
+			}
+		})
+	}
+}
+
+func TestCDIClientTransportOptions(t *testing.T) {
+	testCases := []struct {
+		name           string
+		config         config.Config
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When default options are used",
+			config:      config.Config{},
+			expectedErr: false,
+		},
+		{
+			name:        "When server name matches certificate",
+			config:      config.Config{TLSServerName: "localhost"},
+			expectedErr: false,
+		},
+		{
+			name:           "When server name does not match certificate",
+			config:         config.Config{TLSServerName: "cdi.example.com"},
+			expectedErr:    true,
+			expectedErrMsg: "certificate is valid for",
+		},
+		{
+			name:        "When cipher suite is supported by server",
+			config:      config.Config{TLSCipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			expectedErr: false,
+		},
+		{
+			name:           "When TLS version of server is lower than minimum version",
+			config:         config.Config{TLSMinVersion: "1.3"},
+			expectedErr:    true,
+			expectedErrMsg: "protocol version",
+		},
+		{
+			name:           "When proxy is unreachable",
+			config:         config.Config{ProxyURL: "http://127.0.0.1:1"},
+			expectedErr:    true,
+			expectedErrMsg: "proxyconnect",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, certPem := CreateTLSServer(t)
+			server.TLS.MaxVersion = tls.VersionTLS12
+			server.StartTLS()
+			defer server.Close()
+
+			opts, err := newTransportOptions(&tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tlsConfig, err := buildTLSConfig(map[string][]byte{"certificate": []byte(certPem)}, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			httpClient := &http.Client{Transport: newTransport(tlsConfig, opts)}
+
+			resp, err := httpClient.Get(server.URL)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	testCases := []struct {
+		name          string
+		proxyURL      string
+		expectedProxy string
+	}{
+		{
+			name:          "When proxy url is not set",
+			expectedProxy: "",
+		},
+		{
+			name:          "When proxy url is set",
+			proxyURL:      "http://proxy.example.com:3128",
+			expectedProxy: "http://proxy.example.com:3128",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The environment must not be used for the proxy to CDI
+			t.Setenv("HTTPS_PROXY", "http://env-proxy.example.com:3128")
+			opts, err := newTransportOptions(&config.Config{ProxyURL: tc.proxyURL})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			transport := newTransport(nil, opts)
+			proxy := ""
+			if transport.Proxy != nil {
+				req, _ := http.NewRequest(http.MethodGet, "https://cdi.example.com", nil)
+				proxyURL, err := transport.Proxy(req)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if proxyURL != nil {
+					proxy = proxyURL.String()
+				}
+			}
+			if proxy != tc.expectedProxy {
+				t.Errorf("unexpected proxy, expected %s but got %s", tc.expectedProxy, proxy)
+			}
+		})
+	}
+}
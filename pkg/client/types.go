@@ -1,8 +1,10 @@
 package client
 
 type IMToken struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int64  `json:"expires_in"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
 }
 
 type FMMachineList struct {
@@ -18,6 +20,15 @@ type FMMachine struct {
 	MachineUUID string `json:"mach_uuid"`
 }
 
+// FMEvent is a notification of FM received with Server-Sent Events.
+type FMEvent struct {
+	// Type and ID are the event and id fields of the stream
+	Type        string `json:"-"`
+	ID          string `json:"-"`
+	FabricID    *int   `json:"fabric_id"`
+	MachineUUID string `json:"mach_uuid"`
+}
+
 type FMAvailableReservedResources struct {
 	FabricID            int `json:"fabric_id"`
 	ReservedResourceNum int `json:"reserved_res_num_per_fabric"`
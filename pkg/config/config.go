@@ -20,6 +20,9 @@ import (
 	"fmt"
 	"log/slog"
 	"math/rand"
+	"net/url"
+	"reflect"
+	"regexp"
 	"time"
 
 	validator "github.com/go-playground/validator/v10"
@@ -31,16 +34,111 @@ import (
 const (
 	DeviceInfoKey  = "device-info"
 	LabelPrefixKey = "label-prefix"
+	TenantInfoKey  = "tenant-info"
 )
 
+const (
+	UUIDFormat = "^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$"
+)
+
+const (
+	DefaultNamespace                 = "composable-dra"
+	DefaultConfigMapName             = "composable-dra-dds"
+	DefaultSecretName                = "composable-dra-secret"
+	DefaultCredentialsDir            = "/etc/composable-dra/credentials"
+	DefaultCredentialsReloadInterval = 30 * time.Second
+	DefaultShutdownGracePeriod       = 20 * time.Second
+)
+
+var uuidRegexp = regexp.MustCompile(UUIDFormat)
+
 type Config struct {
-	LogLevel     int
-	ScanInterval time.Duration
-	TenantID     string
-	ClusterID    string
-	CDIEndpoint  string
-	UseCapiBmh   bool
-	UseCM        bool
+	LogLevel                  int
+	LogFormat                 string
+	LogSubsystemLevels        string
+	ScanInterval              time.Duration
+	FabricScanIntervalMin     time.Duration
+	FabricScanIntervalMax     time.Duration
+	FMEvents                  bool
+	TenantID                  string
+	ClusterID                 string
+	CDIEndpoint               string `log:"url"`
+	AllowInsecureHTTP         bool
+	IMEndpoint                string `log:"url"`
+	FMEndpoint                string `log:"url"`
+	CMEndpoint                string `log:"url"`
+	RequestIDHeader           string
+	OTLPEndpoint              string `log:"url"`
+	DryRun                    bool
+	DryRunReport              string
+	ShutdownGracePeriod       time.Duration
+	WithdrawPoolsOnShutdown   bool
+	UseCapiBmh                bool
+	UseCM                     bool
+	Namespace                 string
+	ConfigMapName             string
+	SecretName                string
+	MultiTenant               bool
+	AuthMethod                string
+	TokenRefreshFraction      float64
+	VerifyIMToken             bool
+	IMTokenIssuer             string
+	IMTokenAudience           string
+	CredentialsSource         string
+	CredentialsDir            string
+	CredentialsReloadInterval time.Duration
+	VaultAddress              string `log:"url"`
+	VaultPath                 string
+	VaultTokenFile            string `log:"secret"`
+	TLSMinVersion             string
+	TLSCipherSuites           string
+	TLSServerName             string
+	ProxyURL                  string `log:"url"`
+	MaxIdleConns              int
+	MaxIdleConnsPerHost       int
+	IdleConnTimeout           time.Duration
+	DialTimeout               time.Duration
+	TLSHandshakeTimeout       time.Duration
+}
+
+// maskedValue replaces the settings which must not be logged, in the same way as the password in url.URL.Redacted.
+const maskedValue = "xxxxx"
+
+// LogValue logs every setting, masking the fields by their log tag.
+// Credentials are not part of Config, since they are read from the Secret, files or Vault.
+// The fields tagged with url are logged with their password masked, e.g. the one of the proxy,
+// and the fields tagged with secret, e.g. the path of the Vault token, are masked as a whole.
+func (c *Config) LogValue() slog.Value {
+	v := reflect.ValueOf(c).Elem()
+	var attrs []slog.Attr
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i).Interface()
+		switch field.Tag.Get("log") {
+		case "url":
+			if u, err := url.Parse(v.Field(i).String()); err == nil {
+				value = u.Redacted()
+			} else {
+				value = maskedValue
+			}
+		case "secret":
+			if !v.Field(i).IsZero() {
+				value = maskedValue
+			}
+		}
+		attrs = append(attrs, slog.Any(field.Name, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// ConfigMapKey returns the informer key of the ConfigMap holding device-info and label-prefix.
+func (c *Config) ConfigMapKey() string {
+	return c.Namespace + "/" + c.ConfigMapName
+}
+
+// SecretKey returns the informer key of the Secret holding CDI credentials.
+func (c *Config) SecretKey() string {
+	return c.Namespace + "/" + c.SecretName
 }
 
 type DeviceInfoList struct {
@@ -62,6 +160,25 @@ type DeviceInfo struct {
 	CanNotCoexistWith []int `yaml:"cannot-coexist-with" validate:"required,max=100"`
 }
 
+type TenantInfoList struct {
+	TenantInfos []TenantInfo `yaml:"tenant-info" validate:"unique=Name,unique=TenantID,dive"`
+}
+
+type TenantInfo struct {
+	// Name of tenant. It is used for DRA pool name and label value affixed to a node
+	Name string `yaml:"name" validate:"required,max=20,is-dns"`
+	// ID of tenant registered in CDI
+	TenantID string `yaml:"tenant-id" validate:"required,is-uuid"`
+	// ID of cluster registered in CDI for the tenant. It is required when Cluster Manager is used
+	ClusterID string `yaml:"cluster-id" validate:"omitempty,is-uuid"`
+	// Name of Secret which has credentials and certificate of the tenant
+	SecretName string `yaml:"secret-name" validate:"required,max=253,is-dnsSubdomain"`
+	// Labels of nodes belonging to the tenant
+	NodeSelector map[string]string `yaml:"node-selector" validate:"max=32,dive,keys,is-qualifiedName,endkeys,max=63"`
+	// Name or UUID of node groups in Cluster Manager belonging to the tenant
+	NodeGroups []string `yaml:"node-groups" validate:"max=100,dive,required,max=1000"`
+}
+
 func GetDeviceInfos(cm *corev1.ConfigMap) ([]DeviceInfo, error) {
 	if cm.Data == nil {
 		return nil, fmt.Errorf("configmap data is nil")
@@ -76,70 +193,70 @@ func GetDeviceInfos(cm *corev1.ConfigMap) ([]DeviceInfo, error) {
 			slog.Error("Failed yaml unmarshal", "error", err)
 			return nil, err
 		}
-		var devInfoList DeviceInfoList
-		devInfoList.DeviceInfos = devInfos
 		// Validate the factor in device-info
-		validate := validator.New()
-		validate.RegisterValidation("is-dns", ValidateDNSLabel)
-		validate.RegisterValidation("is-dnsSubdomain", ValidateDNSSubdomain)
-		validate.RegisterValidation("is-qualifiedName", IsQualifiedName)
-		validate.RegisterValidation("has-productName", HasProductName)
-		if err := validate.Struct(devInfoList); err != nil {
-			return nil, err
+		violations := validateEntries(newValidator(), DeviceInfoKey, reflect.TypeOf(DeviceInfoList{}), devInfos, "CDIModelName")
+		if len(violations) > 0 {
+			return nil, &ValidationError{Key: DeviceInfoKey, Violations: violations}
 		}
 		return devInfos, nil
 	}
 }
 
-func ValidateDNSLabel(fl validator.FieldLevel) bool {
-	value := fl.Field().String()
-	errs := validation.IsDNS1123Label(value)
-	if len(errs) > 0 {
-		seen := make(map[string]bool)
-		for _, err := range errs {
-			if !seen[err] {
-				slog.Error("validation error. It must be DNS label", "value", value, "error", err)
-				seen[err] = true
+func GetTenantInfos(cm *corev1.ConfigMap) ([]TenantInfo, error) {
+	if cm.Data == nil {
+		return nil, fmt.Errorf("configmap data is nil")
+	}
+	if tenantInfoStr, found := cm.Data[TenantInfoKey]; !found {
+		return nil, fmt.Errorf("configmap tenant-info is nil")
+	} else {
+		var tenantInfos []TenantInfo
+		err := yaml.Unmarshal([]byte(tenantInfoStr), &tenantInfos)
+		if err != nil {
+			slog.Error("Failed yaml unmarshal", "error", err)
+			return nil, err
+		}
+		if len(tenantInfos) == 0 {
+			return nil, fmt.Errorf("configmap tenant-info is empty")
+		}
+		// Validate the factor in tenant-info
+		violations := validateEntries(newValidator(), TenantInfoKey, reflect.TypeOf(TenantInfoList{}), tenantInfos, "Name")
+		for i, tenantInfo := range tenantInfos {
+			if len(tenantInfo.NodeSelector) == 0 && len(tenantInfo.NodeGroups) == 0 {
+				violations = append(violations, Violation{Path: fmt.Sprintf("%s[%d]", TenantInfoKey, i), Index: i, Entry: tenantInfo.Name, Reason: "must have node-selector or node-groups"})
 			}
 		}
-		return false
-	} else {
-		return true
+		if len(violations) > 0 {
+			return nil, &ValidationError{Key: TenantInfoKey, Violations: violations}
+		}
+		return tenantInfos, nil
 	}
 }
 
+// newValidator returns the validator with the custom validations used in device-info and tenant-info.
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterValidation("is-dns", ValidateDNSLabel)
+	validate.RegisterValidation("is-dnsSubdomain", ValidateDNSSubdomain)
+	validate.RegisterValidation("is-qualifiedName", IsQualifiedName)
+	validate.RegisterValidation("has-productName", HasProductName)
+	validate.RegisterValidation("is-uuid", IsUUID)
+	return validate
+}
+
+func ValidateDNSLabel(fl validator.FieldLevel) bool {
+	return len(validation.IsDNS1123Label(fl.Field().String())) == 0
+}
+
 func ValidateDNSSubdomain(fl validator.FieldLevel) bool {
-	value := fl.Field().String()
-	errs := validation.IsDNS1123Subdomain(value)
-	if len(errs) > 0 {
-		seen := make(map[string]bool)
-		for _, err := range errs {
-			if !seen[err] {
-				slog.Error("validation error. It must be DNS subdomain", "value", value, "error", err)
-				seen[err] = true
-			}
-		}
-		return false
-	} else {
-		return true
-	}
+	return len(validation.IsDNS1123Subdomain(fl.Field().String())) == 0
 }
 
 func IsQualifiedName(fl validator.FieldLevel) bool {
-	value := fl.Field().String()
-	errs := validation.IsQualifiedName(value)
-	if len(errs) > 0 {
-		seen := make(map[string]bool)
-		for _, err := range errs {
-			if !seen[err] {
-				slog.Error("validation error. It must be QualifiedName", "value", value, "error", err)
-				seen[err] = true
-			}
-		}
-		return false
-	} else {
-		return true
-	}
+	return len(validation.IsQualifiedName(fl.Field().String())) == 0
+}
+
+func IsUUID(fl validator.FieldLevel) bool {
+	return uuidRegexp.MatchString(fl.Field().String())
 }
 
 func HasProductName(fl validator.FieldLevel) bool {
@@ -159,20 +276,26 @@ func GetLabelPrefix(cm *corev1.ConfigMap) (string, error) {
 	if labelPrefix, found := cm.Data[LabelPrefixKey]; !found {
 		return "", fmt.Errorf("configmap label-prefix is nil")
 	} else {
-		errs := validation.IsDNS1123Subdomain(labelPrefix)
-		if len(labelPrefix) > 100 {
-			errs = append(errs, "label-prefix length exceeds 100B")
-		}
+		errs := validateLabelPrefix(labelPrefix)
 		if len(errs) > 0 {
+			var violations []Violation
 			for _, err := range errs {
-				slog.Error("validation error for label-prefix", "error", err)
+				violations = append(violations, Violation{Path: LabelPrefixKey, Index: -1, Value: labelPrefix, Reason: err})
 			}
-			return "", fmt.Errorf("label-prefix validation error")
+			return "", &ValidationError{Key: LabelPrefixKey, Violations: violations}
 		}
 		return labelPrefix, nil
 	}
 }
 
+func validateLabelPrefix(labelPrefix string) []string {
+	errs := validation.IsDNS1123Subdomain(labelPrefix)
+	if len(labelPrefix) > 100 {
+		errs = append(errs, "label-prefix length exceeds 100B")
+	}
+	return errs
+}
+
 const CharSet = "123456789"
 
 func RandomString(n int) string {
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -80,6 +81,15 @@ const (
 	CaseLabelPrefixInvalid
 )
 
+const (
+	CaseTenantInfoCorrect = iota
+	CaseTenantInfoNoNodes
+	CaseTenantInfoInvalidTenantID
+	CaseTenantInfoInvalidName
+	CaseTenantInfoDuplicateName
+	CaseTenantInfoUpperCaseTenantID
+)
+
 const (
 	CaseSecretCorrect = iota + 1
 	CaseSecretTooLongUserName
@@ -92,14 +102,36 @@ const (
 	CaseSecretInvalidAccessToken
 	CaseSecretFailedDecodeToken
 	CaseSecretNotJsonToken
+	CaseSecretClientCredentials
+	CaseSecretRefreshToken
+	CaseSecretPrivateKeyJWT
+	CaseSecretTLSClientAuth
+	CaseSecretInvalidAuthMethod
+	CaseSecretSignedToken
 )
 
+const TestRefreshToken = "offline-token"
+
 var FullLengthModel string = RandomString(1000)
 var FullLengthAttrKey string = RandomString(63) + "/" + RandomString(32)
 var FullLengthAttrValue string = RandomString(64)
 var FullLengthDriverName string = RandomString(63)
 var FullLengthDeviceName string = RandomString(50)
 
+var (
+	testClientKeysOnce                                     sync.Once
+	testPrivateKeyPem, testClientCertPem, testClientKeyPem string
+)
+
+// TestClientKeys returns a private key for client assertion, and a client certificate and its key for mutual TLS.
+// The keys are created on the first call, so that only tests pay for them.
+func TestClientKeys() (privateKeyPem, clientCertPem, clientKeyPem string) {
+	testClientKeysOnce.Do(func() {
+		testPrivateKeyPem, testClientCertPem, testClientKeyPem = createTestClientKeys()
+	})
+	return testPrivateKeyPem, testClientCertPem, testClientKeyPem
+}
+
 var ExceededSecretInfo string = RandomString(1000)
 var UnExceededSecretInfo string = RandomString(999)
 
@@ -123,6 +155,25 @@ type TestSpec struct {
 	CertPem              string
 	TenantID             string
 	ClusterID            string
+	// Namespace and SecretName replace the default ones of the test Secret if set
+	Namespace  string
+	SecretName string
+}
+
+// NamespaceOrDefault returns the namespace where the test Secret is created.
+func (s TestSpec) NamespaceOrDefault() string {
+	if len(s.Namespace) > 0 {
+		return s.Namespace
+	}
+	return DefaultNamespace
+}
+
+// SecretNameOrDefault returns the name of the test Secret.
+func (s TestSpec) SecretNameOrDefault() string {
+	if len(s.SecretName) > 0 {
+		return s.SecretName
+	}
+	return DefaultSecretName
 }
 
 func CreateDeviceInfos(devInfoCase int) []DeviceInfo {
@@ -343,6 +394,77 @@ func CreateDeviceInfos(devInfoCase int) []DeviceInfo {
 	return devInfos
 }
 
+func CreateTenantInfos(tenantInfoCase int) []TenantInfo {
+	tenantInfo0 := TenantInfo{
+		Name:       "tenant-a",
+		TenantID:   "00000000-0000-0001-0000-000000000000",
+		ClusterID:  "00000000-0000-0000-0001-000000000000",
+		SecretName: "composable-dra-secret-a",
+		NodeSelector: map[string]string{
+			"example.com/tenant": "a",
+		},
+	}
+	tenantInfo1 := TenantInfo{
+		Name:       "tenant-b",
+		TenantID:   "00000000-0000-0002-0000-000000000000",
+		ClusterID:  "00000000-0000-0000-0001-000000000000",
+		SecretName: "composable-dra-secret-b",
+		NodeGroups: []string{"NodeGroup1"},
+	}
+
+	tenantInfos := []TenantInfo{tenantInfo0, tenantInfo1}
+
+	switch tenantInfoCase {
+	case CaseTenantInfoNoNodes:
+		tenantInfo := tenantInfos[0]
+		tenantInfo.NodeSelector = nil
+		tenantInfos = []TenantInfo{tenantInfo}
+
+	case CaseTenantInfoInvalidTenantID:
+		tenantInfo := tenantInfos[0]
+		tenantInfo.TenantID = "not-uuid"
+		tenantInfos = []TenantInfo{tenantInfo}
+
+	case CaseTenantInfoInvalidName:
+		tenantInfo := tenantInfos[0]
+		tenantInfo.Name = "Tenant_A"
+		tenantInfos = []TenantInfo{tenantInfo}
+
+	case CaseTenantInfoDuplicateName:
+		tenantInfo := tenantInfos[1]
+		tenantInfo.Name = "tenant-a"
+		tenantInfos = []TenantInfo{tenantInfos[0], tenantInfo}
+
+	case CaseTenantInfoUpperCaseTenantID:
+		tenantInfo := tenantInfos[0]
+		tenantInfo.TenantID = "ABCDEF00-0000-0001-0000-000000000000"
+		tenantInfos = []TenantInfo{tenantInfo}
+
+	default:
+	}
+	return tenantInfos
+}
+
+func CreateTenantInfoConfigMap(tenantInfoCase int) (*corev1.ConfigMap, error) {
+	data, err := yaml.Marshal(CreateTenantInfos(tenantInfoCase))
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("test-tenant-configmap-%d", tenantInfoCase),
+			Namespace: "composable-dra",
+		},
+		Data: map[string]string{
+			TenantInfoKey: string(data),
+		},
+	}
+	return cm, nil
+}
+
 func CreateLabelPrefix(labelPrefixCase int) string {
 	switch labelPrefixCase {
 	case CaseLabelPrefix100B:
@@ -481,11 +603,79 @@ func CreateSecret(certPem string, secretCase int) *corev1.Secret {
 		secret.Data["realm"] = []byte("Decode_Test")
 	case CaseSecretNotJsonToken:
 		secret.Data["realm"] = []byte("NotJson_Test")
+
+	case CaseSecretClientCredentials:
+		secret.Data["auth_method"] = []byte("client_credentials")
+		delete(secret.Data, "username")
+		delete(secret.Data, "password")
+
+	case CaseSecretRefreshToken:
+		secret.Data["auth_method"] = []byte("refresh_token")
+		secret.Data["refresh_token"] = []byte(TestRefreshToken)
+		delete(secret.Data, "username")
+		delete(secret.Data, "password")
+
+	case CaseSecretPrivateKeyJWT:
+		secret.Data["auth_method"] = []byte("private_key_jwt")
+		privateKeyPem, _, _ := TestClientKeys()
+		secret.Data["private_key"] = []byte(privateKeyPem)
+		secret.Data["private_key_id"] = []byte("test-key")
+		delete(secret.Data, "username")
+		delete(secret.Data, "password")
+		delete(secret.Data, "client_secret")
+
+	case CaseSecretTLSClientAuth:
+		secret.Data["auth_method"] = []byte("tls_client_auth")
+		_, clientCertPem, clientKeyPem := TestClientKeys()
+		secret.Data["client_certificate"] = []byte(clientCertPem)
+		secret.Data["client_key"] = []byte(clientKeyPem)
+		delete(secret.Data, "username")
+		delete(secret.Data, "password")
+		delete(secret.Data, "client_secret")
+
+	case CaseSecretInvalidAuthMethod:
+		secret.Data["auth_method"] = []byte("unknown")
+
+	case CaseSecretSignedToken:
+		secret.Data["realm"] = []byte("Signed_Test")
 	default:
 	}
 	return secret
 }
 
+// createTestClientKeys creates a private key for client assertion, and a self-signed client certificate and its key for mutual TLS.
+func createTestClientKeys() (privateKeyPem, clientCertPem, clientKeyPem string) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	privateKeyPem = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	created := time.Now()
+	clientTpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:   "client-composable-dra-dds-test",
+			Organization: []string{"composable-dra-dds"},
+		},
+		NotBefore:   created,
+		NotAfter:    created.Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	derClientCertificate, err := x509.CreateCertificate(rand.Reader, clientTpl, clientTpl, clientKey.Public(), clientKey)
+	if err != nil {
+		panic(err)
+	}
+	clientCertPem = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derClientCertificate}))
+	clientKeyPem = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(clientKey)}))
+	return privateKeyPem, clientCertPem, clientKeyPem
+}
+
 type CertData struct {
 	PrivKey crypto.Signer
 	CertPem string
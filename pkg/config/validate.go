@@ -0,0 +1,299 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	validator "github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Violation is a failure of validation in a ConfigMap.
+type Violation struct {
+	// Path of YAML keys, e.g. device-info[0].k8s-device-name
+	Path string
+	// Index of the entry in device-info or tenant-info, or -1 if the violation is not in an entry
+	Index int
+	// Entry is cdi-model-name of device-info or name of tenant-info
+	Entry string
+	// Value violating the rule. It is empty for lists and maps
+	Value string
+	// Reason is human-readable
+	Reason string
+}
+
+func (v Violation) String() string {
+	s := v.Path
+	if len(v.Entry) > 0 {
+		s += fmt.Sprintf(" (%s)", truncate(v.Entry))
+	}
+	s += ": " + v.Reason
+	if len(v.Value) > 0 {
+		s += fmt.Sprintf(", value %q", truncate(v.Value))
+	}
+	return s
+}
+
+// truncate shortens a long value such as a name of 1000B in messages.
+func truncate(s string) string {
+	if len(s) > maxMessageValueLength {
+		return s[:maxMessageValueLength] + "..."
+	}
+	return s
+}
+
+// ValidationError has every violation of device-info, label-prefix or tenant-info.
+type ValidationError struct {
+	Key        string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var violations []string
+	for _, violation := range e.Violations {
+		violations = append(violations, violation.String())
+	}
+	return fmt.Sprintf("%s validation error: %s", e.Key, strings.Join(violations, "; "))
+}
+
+const maxMessageValueLength = 64
+
+var namespaceSegmentRegexp = regexp.MustCompile(`^([^\[]+)(.*)$`)
+
+// LoadConfigMapFile reads a ConfigMap manifest, or raw YAML of its data such as
+// {device-info: [...], label-prefix: ...}, or a raw device-info list.
+func LoadConfigMapFile(path string) (*corev1.ConfigMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{Data: make(map[string]string)}
+	switch content := raw.(type) {
+	case []interface{}:
+		cm.Data[DeviceInfoKey] = string(data)
+	case map[interface{}]interface{}:
+		if content["kind"] == "ConfigMap" {
+			if err := sigsyaml.Unmarshal(data, cm); err != nil {
+				return nil, err
+			}
+			return cm, nil
+		}
+		for key, value := range content {
+			keyStr := fmt.Sprint(key)
+			if valueStr, ok := value.(string); ok {
+				cm.Data[keyStr] = valueStr
+				continue
+			}
+			valueBytes, err := yaml.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			cm.Data[keyStr] = string(valueBytes)
+		}
+	default:
+		return nil, fmt.Errorf("file must be a ConfigMap manifest or YAML of device-info")
+	}
+	return cm, nil
+}
+
+// ValidateConfigMap validates device-info, label-prefix and tenant-info which are set, and returns every violation.
+func ValidateConfigMap(cm *corev1.ConfigMap) []Violation {
+	var violations []Violation
+	found := false
+	if _, exist := cm.Data[DeviceInfoKey]; exist {
+		found = true
+		_, err := GetDeviceInfos(cm)
+		violations = append(violations, toViolations(DeviceInfoKey, err)...)
+	}
+	if _, exist := cm.Data[LabelPrefixKey]; exist {
+		found = true
+		_, err := GetLabelPrefix(cm)
+		violations = append(violations, toViolations(LabelPrefixKey, err)...)
+	}
+	if _, exist := cm.Data[TenantInfoKey]; exist {
+		found = true
+		_, err := GetTenantInfos(cm)
+		violations = append(violations, toViolations(TenantInfoKey, err)...)
+	}
+	if !found {
+		violations = append(violations, Violation{Path: ".", Index: -1, Reason: fmt.Sprintf("none of %s, %s and %s is found", DeviceInfoKey, LabelPrefixKey, TenantInfoKey)})
+	}
+	return violations
+}
+
+func toViolations(key string, err error) []Violation {
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Violations
+	}
+	return []Violation{{Path: key, Index: -1, Reason: err.Error()}}
+}
+
+// validateEntries validates every entry of device-info or tenant-info even if another entry is invalid.
+// listType is DeviceInfoList or TenantInfoList, and nameField is the field naming an entry.
+func validateEntries(validate *validator.Validate, key string, listType reflect.Type, entries interface{}, nameField string) []Violation {
+	var violations []Violation
+	list := reflect.ValueOf(entries)
+	entryType := listType.Field(0).Type.Elem()
+	entryName := func(i int) string {
+		return list.Index(i).FieldByName(nameField).String()
+	}
+
+	// Rules of the list itself are the ones before dive
+	for _, rule := range strings.Split(listType.Field(0).Tag.Get("validate"), ",") {
+		if rule == "dive" {
+			break
+		}
+		field, isUnique := strings.CutPrefix(rule, "unique=")
+		if !isUnique {
+			if err := validate.Var(entries, rule); err != nil {
+				violations = append(violations, Violation{Path: key, Index: -1, Reason: fmt.Sprintf("failed on '%s' validation", rule)})
+			}
+			continue
+		}
+		structField, _ := entryType.FieldByName(field)
+		firstIndex := make(map[interface{}]int)
+		for i := 0; i < list.Len(); i++ {
+			value := list.Index(i).FieldByName(field).Interface()
+			if first, exist := firstIndex[value]; exist {
+				violations = append(violations, Violation{
+					Path:   fmt.Sprintf("%s[%d].%s", key, i, yamlName(structField)),
+					Index:  i,
+					Entry:  entryName(i),
+					Value:  fmt.Sprint(value),
+					Reason: fmt.Sprintf("must be unique, but same as %s[%d]", key, first),
+				})
+				continue
+			}
+			firstIndex[value] = i
+		}
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		err := validate.Struct(list.Index(i).Interface())
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			if err != nil {
+				violations = append(violations, Violation{Path: fmt.Sprintf("%s[%d]", key, i), Index: i, Entry: entryName(i), Reason: err.Error()})
+			}
+			continue
+		}
+		for _, fieldErr := range fieldErrs {
+			violation := Violation{
+				Path:   fmt.Sprintf("%s[%d].%s", key, i, yamlPath(fieldErr.StructNamespace(), entryType)),
+				Index:  i,
+				Entry:  entryName(i),
+				Reason: reason(fieldErr),
+			}
+			if kind := fieldErr.Kind(); kind != reflect.Slice && kind != reflect.Map {
+				violation.Value = fmt.Sprint(fieldErr.Value())
+			}
+			violations = append(violations, violation)
+		}
+	}
+	// Sort by entry, keeping the order of fields in an entry
+	slices.SortStableFunc(violations, func(a, b Violation) int {
+		return a.Index - b.Index
+	})
+	return violations
+}
+
+// reason returns the human-readable reason of the failure of a validation tag.
+func reason(fieldErr validator.FieldError) string {
+	value := fmt.Sprint(fieldErr.Value())
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "required":
+		return "must be set"
+	case "max":
+		switch fieldErr.Kind() {
+		case reflect.String:
+			return fmt.Sprintf("length must be at most %sB", param)
+		case reflect.Slice, reflect.Map:
+			return fmt.Sprintf("must have at most %s items", param)
+		}
+		return fmt.Sprintf("must be at most %s", param)
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", param)
+	case "lte":
+		return fmt.Sprintf("must be less than or equal to %s", param)
+	case "is-dns":
+		return "must be DNS label: " + strings.Join(validation.IsDNS1123Label(value), ", ")
+	case "is-dnsSubdomain":
+		return "must be DNS subdomain: " + strings.Join(validation.IsDNS1123Subdomain(value), ", ")
+	case "is-qualifiedName":
+		return "must be qualified name: " + strings.Join(validation.IsQualifiedName(value), ", ")
+	case "is-uuid":
+		return "must be UUID"
+	case "has-productName":
+		return "must have productName"
+	}
+	if len(param) > 0 {
+		return fmt.Sprintf("failed on '%s=%s' validation", fieldErr.Tag(), param)
+	}
+	return fmt.Sprintf("failed on '%s' validation", fieldErr.Tag())
+}
+
+// yamlPath converts the struct namespace of validator like DeviceInfo.DRAAttributes[productName]
+// to the path of YAML keys like dra-attributes[productName].
+func yamlPath(namespace string, t reflect.Type) string {
+	segments := strings.Split(namespace, ".")
+	var path []string
+	// The first segment is the name of the root struct
+	for _, segment := range segments[1:] {
+		matches := namespaceSegmentRegexp.FindStringSubmatch(segment)
+		if matches == nil || t.Kind() != reflect.Struct {
+			path = append(path, segment)
+			continue
+		}
+		field, ok := t.FieldByName(matches[1])
+		if !ok {
+			path = append(path, segment)
+			continue
+		}
+		path = append(path, yamlName(field)+matches[2])
+		t = field.Type
+		for t.Kind() == reflect.Slice || t.Kind() == reflect.Map || t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+func yamlName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("yaml"), ",")[0]; len(name) > 0 {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testDeviceInfo = `- index: 1
+  cdi-model-name: "A100 40G"
+  dra-attributes:
+    productName: "NVIDIA A100 40GB"
+  driver-name: "gpu.nvidia.com"
+  k8s-device-name: "nvidia-a100-40"
+  cannot-coexist-with: [2]
+`
+
+func TestLoadConfigMapFile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectedData   map[string]string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name: "When ConfigMap manifest is loaded",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: composable-dra-dds
+data:
+  label-prefix: cohdi.com
+  device-info: |
+` + indent(testDeviceInfo, "    "),
+			expectedData: map[string]string{
+				LabelPrefixKey: "cohdi.com",
+				DeviceInfoKey:  testDeviceInfo,
+			},
+		},
+		{
+			name:    "When raw device-info list is loaded",
+			content: testDeviceInfo,
+			expectedData: map[string]string{
+				DeviceInfoKey: testDeviceInfo,
+			},
+		},
+		{
+			name:    "When raw data is loaded",
+			content: "label-prefix: cohdi.com\ndevice-info:\n" + testDeviceInfo,
+			expectedData: map[string]string{
+				LabelPrefixKey: "cohdi.com",
+			},
+		},
+		{
+			name:           "When file is not YAML of ConfigMap",
+			content:        "cohdi.com",
+			expectedErr:    true,
+			expectedErrMsg: "file must be a ConfigMap manifest or YAML of device-info",
+		},
+		{
+			name:           "When file is not YAML",
+			content:        "device-info: [",
+			expectedErr:    true,
+			expectedErrMsg: "yaml",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "device-info.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0600); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			cm, err := LoadConfigMapFile(path)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for key, expected := range tc.expectedData {
+				if cm.Data[key] != expected {
+					t.Errorf("unexpected %s, expected %q but got %q", key, expected, cm.Data[key])
+				}
+			}
+			if _, err := GetDeviceInfos(cm); err != nil {
+				t.Errorf("unexpected error in device-info: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateConfigMap(t *testing.T) {
+	testCases := []struct {
+		name          string
+		data          map[string]string
+		expectedPaths []string
+	}{
+		{
+			name: "When ConfigMap is valid",
+			data: map[string]string{
+				DeviceInfoKey:  testDeviceInfo,
+				LabelPrefixKey: "cohdi.com",
+			},
+		},
+		{
+			name: "When every entry has violations",
+			data: map[string]string{
+				DeviceInfoKey: testDeviceInfo + `- index: 1
+  cdi-model-name: ""
+  dra-attributes:
+    vendor: "NVIDIA"
+  driver-name: "gpu.nvidia.com"
+  k8s-device-name: "Nvidia_A100"
+  cannot-coexist-with: [1]
+`,
+				LabelPrefixKey: "Cohdi_com",
+			},
+			expectedPaths: []string{
+				"device-info[1].index",
+				"device-info[1].cdi-model-name",
+				"device-info[1].dra-attributes",
+				"device-info[1].k8s-device-name",
+				"label-prefix",
+			},
+		},
+		{
+			name: "When dra-attributes has invalid key",
+			data: map[string]string{
+				DeviceInfoKey: strings.Replace(testDeviceInfo, `productName: "NVIDIA A100 40GB"`, "productName: a\n    -invalid-: b", 1),
+			},
+			expectedPaths: []string{
+				"device-info[0].dra-attributes[-invalid-]",
+			},
+		},
+		{
+			name: "When tenant-info has violations",
+			data: map[string]string{
+				TenantInfoKey: `- name: tenant-a
+  tenant-id: invalid
+  secret-name: secret-a
+`,
+			},
+			expectedPaths: []string{
+				"tenant-info[0].tenant-id",
+				"tenant-info[0]",
+			},
+		},
+		{
+			name:          "When device-info is not YAML",
+			data:          map[string]string{DeviceInfoKey: "- index: ["},
+			expectedPaths: []string{"device-info"},
+		},
+		{
+			name:          "When nothing is set",
+			data:          map[string]string{},
+			expectedPaths: []string{"."},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			violations := ValidateConfigMap(&corev1.ConfigMap{Data: tc.data})
+			var paths []string
+			for _, violation := range violations {
+				paths = append(paths, violation.Path)
+				if len(violation.Reason) == 0 {
+					t.Errorf("reason of %s is empty", violation.Path)
+				}
+			}
+			if !reflect.DeepEqual(paths, tc.expectedPaths) {
+				t.Errorf("unexpected violations, expected %v but got %v", tc.expectedPaths, violations)
+			}
+		})
+	}
+}
+
+func indent(s string, prefix string) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix) + "\n"
+}
+
+func TestGetDeviceInfosValidationError(t *testing.T) {
+	cm := &corev1.ConfigMap{Data: map[string]string{
+		DeviceInfoKey: testDeviceInfo + `- index: 2
+  cdi-model-name: "A100 80G"
+  dra-attributes:
+    productName: "NVIDIA A100 80GB"
+  driver-name: "gpu.nvidia.com"
+  k8s-device-name: "Nvidia_A100"
+  cannot-coexist-with: [1]
+- index: 2
+  cdi-model-name: "H100"
+  dra-attributes:
+    vendor: "NVIDIA"
+  driver-name: "gpu.nvidia.com"
+  k8s-device-name: "nvidia-h100"
+  cannot-coexist-with: []
+`,
+	}}
+	expectedViolations := []Violation{
+		{Path: "device-info[1].k8s-device-name", Index: 1, Entry: "A100 80G", Value: "Nvidia_A100"},
+		{Path: "device-info[2].index", Index: 2, Entry: "H100", Value: "2"},
+		{Path: "device-info[2].dra-attributes", Index: 2, Entry: "H100"},
+	}
+
+	_, err := GetDeviceInfos(cm)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+	if validationErr.Key != DeviceInfoKey {
+		t.Errorf("unexpected key, expected %s but got %s", DeviceInfoKey, validationErr.Key)
+	}
+	if len(validationErr.Violations) != len(expectedViolations) {
+		t.Fatalf("unexpected violations, expected %v but got %v", expectedViolations, validationErr.Violations)
+	}
+	for i, expected := range expectedViolations {
+		violation := validationErr.Violations[i]
+		if violation.Path != expected.Path || violation.Index != expected.Index || violation.Entry != expected.Entry || violation.Value != expected.Value {
+			t.Errorf("unexpected violation, expected %+v but got %+v", expected, violation)
+		}
+		if len(violation.Reason) == 0 {
+			t.Errorf("reason of %s is empty", violation.Path)
+		}
+		if !strings.Contains(err.Error(), violation.Path) {
+			t.Errorf("error does not include %s: %v", violation.Path, err)
+		}
+	}
+}
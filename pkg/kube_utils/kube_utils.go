@@ -83,12 +83,17 @@ func NewClientConfig() (*rest.Config, error) {
 	return config, nil
 }
 
-func CreateKubeControllers(coreClient kube_client.Interface, bmhClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, useCapiBmh bool, stopChannel <-chan struct{}) (*KubeControllers, error) {
-	coreInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(coreClient, 0, kubeinformers.WithNamespace("composable-dra"))
+func CreateKubeControllers(coreClient kube_client.Interface, bmhClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, useCapiBmh bool, watchSecrets bool, namespace string, stopChannel <-chan struct{}) (*KubeControllers, error) {
+	// ConfigMaps and Secrets are only watched in the namespace where CDI_DRA is installed
+	coreInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(coreClient, 0, kubeinformers.WithNamespace(namespace))
 	bmhInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(bmhClient, 0)
 
 	configMapInformer := coreInformerFactory.Core().V1().ConfigMaps().Informer()
-	secretInformer := coreInformerFactory.Core().V1().Secrets().Informer()
+	// Secrets are not watched when credentials are read from other sources, so that list/watch on secrets is not required
+	var secretInformer cache.SharedIndexInformer
+	if watchSecrets {
+		secretInformer = coreInformerFactory.Core().V1().Secrets().Informer()
+	}
 	nodeInformer := coreInformerFactory.Core().V1().Nodes()
 	if err := nodeInformer.Informer().GetIndexer().AddIndexers(cache.Indexers{
 		nodeProviderIDIndex: indexNodeByProviderID,
@@ -197,7 +202,9 @@ func (kc *KubeControllers) Run() error {
 	syncFuncs := []cache.InformerSynced{
 		kc.nodeInformer.Informer().HasSynced,
 		kc.configMapInformer.HasSynced,
-		kc.secretInformer.HasSynced,
+	}
+	if kc.secretInformer != nil {
+		syncFuncs = append(syncFuncs, kc.secretInformer.HasSynced)
 	}
 	if kc.bmhAvailable {
 		syncFuncs = append(syncFuncs, kc.bmhInformer.Informer().HasSynced)
@@ -242,6 +249,9 @@ func (kc *KubeControllers) GetConfigMap(key string) (*corev1.ConfigMap, error) {
 }
 
 func (kc *KubeControllers) GetSecret(key string) (*corev1.Secret, error) {
+	if kc.secretInformer == nil {
+		return nil, fmt.Errorf("secrets are not watched")
+	}
 	obj, exists, err := kc.secretInformer.GetIndexer().GetByKey(key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secret: %w", err)
@@ -258,6 +268,56 @@ func (kc *KubeControllers) GetSecret(key string) (*corev1.Secret, error) {
 	return secret.DeepCopy(), nil
 }
 
+// AddSecretHandler registers the handler called with the old and new secret when the secret of the key is changed.
+// The old secret is nil when the secret is created, and the new secret is nil when it is deleted.
+// The secret existing when the handler is registered is not reported as created.
+func (kc *KubeControllers) AddSecretHandler(key string, handler func(oldSecret, newSecret *corev1.Secret)) error {
+	if kc.secretInformer == nil {
+		return fmt.Errorf("secrets are not watched")
+	}
+	watchedSecret := func(obj interface{}) (*corev1.Secret, bool) {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil, false
+		}
+		if secretKey, err := cache.MetaNamespaceKeyFunc(secret); err != nil || secretKey != key {
+			return nil, false
+		}
+		return secret, true
+	}
+	_, err := kc.secretInformer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if isInInitialList {
+				return
+			}
+			if newSecret, ok := watchedSecret(obj); ok {
+				handler(nil, newSecret.DeepCopy())
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, ok := oldObj.(*corev1.Secret)
+			if !ok {
+				return
+			}
+			if newSecret, ok := watchedSecret(newObj); ok {
+				handler(oldSecret.DeepCopy(), newSecret.DeepCopy())
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if oldSecret, ok := watchedSecret(obj); ok {
+				handler(oldSecret.DeepCopy(), nil)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add secret handler: %w", err)
+	}
+	return nil
+}
+
 func (kc *KubeControllers) ListProviderIDs() ([]normalizedProviderID, error) {
 	var providerIDs []normalizedProviderID
 
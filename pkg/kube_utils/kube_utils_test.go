@@ -18,11 +18,13 @@ package kube_utils
 
 import (
 	"cdi_dra/pkg/config"
+	"context"
 	"log/slog"
 	"os"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -352,6 +354,137 @@ func TestKubeControllersGetSecret(t *testing.T) {
 	}
 }
 
+func TestKubeControllersWithoutSecrets(t *testing.T) {
+	testConfig := &config.TestConfig{
+		Secret: config.CreateSecret("", config.CaseSecretCorrect),
+	}
+	kubeclient, dynamicclient := CreateTestClient(t, testConfig)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	controllers, err := CreateKubeControllers(kubeclient, dynamicclient, kubeclient.Discovery(), false, false, config.DefaultNamespace, stopCh)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := controllers.Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := controllers.GetSecret("composable-dra/composable-dra-secret"); err == nil || err.Error() != "secrets are not watched" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := controllers.AddSecretHandler("composable-dra/composable-dra-secret", func(_, _ *corev1.Secret) {}); err == nil {
+		t.Error("expected error, but got none")
+	}
+}
+
+func TestKubeControllersAddSecretHandler(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secretName     string
+		expectedCalled bool
+	}{
+		{
+			name:           "When watched Secret is updated",
+			secretName:     "composable-dra-secret",
+			expectedCalled: true,
+		},
+		{
+			name:           "When other Secret is updated",
+			secretName:     "other-secret",
+			expectedCalled: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret := config.CreateSecret("", config.CaseSecretCorrect)
+			otherSecret := config.CreateSecret("", config.CaseSecretCorrect)
+			otherSecret.Name = "other-secret"
+			testConfig := &config.TestConfig{
+				Secret: secret,
+			}
+			kubeclient, dynamicclient := CreateTestClient(t, testConfig)
+			if _, err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Create(context.Background(), otherSecret, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("failed to create secret: %v", err)
+			}
+			controllers, stopController := CreateTestKubeControllers(t, testConfig, kubeclient, dynamicclient)
+			defer stopController()
+
+			called := make(chan string, 1)
+			err := controllers.AddSecretHandler("composable-dra/composable-dra-secret", func(oldSecret, newSecret *corev1.Secret) {
+				called <- string(oldSecret.Data["password"]) + "->" + string(newSecret.Data["password"])
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			updated := config.CreateSecret("", config.CaseSecretCorrect)
+			updated.Name = tc.secretName
+			updated.Data["password"] = []byte("new-pass")
+			if _, err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("failed to update secret: %v", err)
+			}
+
+			select {
+			case change := <-called:
+				if !tc.expectedCalled {
+					t.Errorf("unexpected handler call: %s", change)
+				} else if change != "pass->new-pass" {
+					t.Errorf("unexpected secret change, expected pass->new-pass but got %s", change)
+				}
+			case <-time.After(2 * time.Second):
+				if tc.expectedCalled {
+					t.Error("expected handler call, but got none")
+				}
+			}
+		})
+	}
+}
+
+func TestKubeControllersAddSecretHandlerRecreate(t *testing.T) {
+	secret := config.CreateSecret("", config.CaseSecretCorrect)
+	testConfig := &config.TestConfig{
+		Secret: secret,
+	}
+	kubeclient, dynamicclient := CreateTestClient(t, testConfig)
+	controllers, stopController := CreateTestKubeControllers(t, testConfig, kubeclient, dynamicclient)
+	defer stopController()
+
+	called := make(chan string, 3)
+	err := controllers.AddSecretHandler("composable-dra/composable-dra-secret", func(oldSecret, newSecret *corev1.Secret) {
+		var oldPassword, newPassword string
+		if oldSecret != nil {
+			oldPassword = string(oldSecret.Data["password"])
+		}
+		if newSecret != nil {
+			newPassword = string(newSecret.Data["password"])
+		}
+		called <- oldPassword + "->" + newPassword
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Delete(context.Background(), secret.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+	recreated := config.CreateSecret("", config.CaseSecretCorrect)
+	recreated.Data["password"] = []byte("new-pass")
+	if _, err := kubeclient.CoreV1().Secrets(config.DefaultNamespace).Create(context.Background(), recreated, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create secret: %v", err)
+	}
+
+	// The secret existing at registration is not reported as created
+	for _, expected := range []string{"pass->", "->new-pass"} {
+		select {
+		case change := <-called:
+			if change != expected {
+				t.Errorf("unexpected secret change, expected %s but got %s", expected, change)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected handler call for %s, but got none", expected)
+		}
+	}
+}
+
 func TestKubeControllersListProviderIDs(t *testing.T) {
 	testCases := []struct {
 		name                     string
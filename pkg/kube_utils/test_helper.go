@@ -103,7 +103,7 @@ func CreateTestClient(t testing.TB, testConfig *config.TestConfig) (*fakekube.Cl
 func CreateTestKubeControllers(t testing.TB, testConfig *config.TestConfig, kubeclient kube_client.Interface, dynamicclient dynamic.Interface) (*KubeControllers, TestControllerShutdownFunc) {
 	discoveryclient := kubeclient.Discovery()
 	stopCh := make(chan struct{})
-	controllers, err := CreateKubeControllers(kubeclient, dynamicclient, discoveryclient, testConfig.Spec.UseCapiBmh, stopCh)
+	controllers, err := CreateKubeControllers(kubeclient, dynamicclient, discoveryclient, testConfig.Spec.UseCapiBmh, true, testConfig.Spec.NamespaceOrDefault(), stopCh)
 	if err != nil {
 		t.Fatal("failed to create test controller")
 	}
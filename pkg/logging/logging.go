@@ -0,0 +1,183 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var Formats = []string{FormatText, FormatJSON}
+
+// Subsystems are the packages of CDI_DRA whose log level can be set apart from the default level
+var Subsystems = []string{"client", "config", "kube_utils", "manager", "tracing"}
+
+// modulePrefix is the prefix of the functions in the packages of Subsystems
+const modulePrefix = "cdi_dra/pkg/"
+
+type Options struct {
+	// Format is text or json
+	Format string
+	// Level is the default level of every log
+	Level slog.Level
+	// SubsystemLevels override Level for the logs from the subsystems
+	SubsystemLevels map[string]slog.Level
+}
+
+// NewLogger creates a logger writing to w in the format, with the source file and line of each log.
+// Secrets in the logs are redacted.
+func NewLogger(w io.Writer, opts Options) (*slog.Logger, error) {
+	minLevel := opts.Level
+	for _, level := range opts.SubsystemLevels {
+		minLevel = min(minLevel, level)
+	}
+	handlerOpts := &slog.HandlerOptions{
+		AddSource:   true,
+		Level:       minLevel,
+		ReplaceAttr: replaceAttr,
+	}
+	var handler slog.Handler
+	switch opts.Format {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", opts.Format)
+	}
+	if len(opts.SubsystemLevels) > 0 {
+		handler = &subsystemHandler{
+			handler:  handler,
+			level:    opts.Level,
+			levels:   opts.SubsystemLevels,
+			minLevel: minLevel,
+		}
+	}
+	return slog.New(&redactHandler{handler: handler}), nil
+}
+
+// ParseLevel parses a level name, i.e. debug, info, warn or error, or an integer level as -v.
+func ParseLevel(level string) (slog.Level, error) {
+	if n, err := strconv.Atoi(level); err == nil {
+		return slog.Level(n), nil
+	}
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unsupported log level: %s", level)
+}
+
+// ParseSubsystemLevels parses levels of subsystems in the form of client=debug,manager=warn.
+func ParseSubsystemLevels(levels string) (map[string]slog.Level, error) {
+	result := make(map[string]slog.Level)
+	for _, entry := range strings.Split(levels, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		subsystem, levelName, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("subsystem log level must be set as <subsystem>=<level>: %s", entry)
+		}
+		subsystem = strings.TrimSpace(subsystem)
+		if !slices.Contains(Subsystems, subsystem) {
+			return nil, fmt.Errorf("unsupported subsystem: %s", subsystem)
+		}
+		level, err := ParseLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return nil, err
+		}
+		result[subsystem] = level
+	}
+	return result, nil
+}
+
+// replaceAttr shortens the source to the file name and line.
+func replaceAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.SourceKey {
+		if source, ok := attr.Value.Any().(*slog.Source); ok && source != nil {
+			attr.Value = slog.StringValue(fmt.Sprintf("%s:%d", filepath.Base(source.File), source.Line))
+		}
+	}
+	return attr
+}
+
+// subsystemHandler filters logs by the level of the subsystem the log is called from.
+type subsystemHandler struct {
+	handler  slog.Handler
+	level    slog.Level
+	levels   map[string]slog.Level
+	minLevel slog.Level
+}
+
+func (h *subsystemHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.minLevel
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	level := h.level
+	if subsystemLevel, ok := h.levels[subsystemOf(r.PC)]; ok {
+		level = subsystemLevel
+	}
+	if r.Level < level {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &subsystemHandler{handler: h.handler.WithAttrs(attrs), level: h.level, levels: h.levels, minLevel: h.minLevel}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{handler: h.handler.WithGroup(name), level: h.level, levels: h.levels, minLevel: h.minLevel}
+}
+
+// subsystemOf returns the package under cdi_dra/pkg of the function at pc, e.g. client.
+func subsystemOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	function, ok := strings.CutPrefix(frame.Function, modulePrefix)
+	if !ok {
+		return ""
+	}
+	if i := strings.IndexAny(function, "./"); i >= 0 {
+		return function[:i]
+	}
+	return function
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"cdi_dra/pkg/config"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		level          string
+		expectedLevel  slog.Level
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:          "When level is debug",
+			level:         "debug",
+			expectedLevel: slog.LevelDebug,
+		},
+		{
+			name:          "When level is upper case",
+			level:         "WARN",
+			expectedLevel: slog.LevelWarn,
+		},
+		{
+			name:          "When level is integer",
+			level:         "8",
+			expectedLevel: slog.LevelError,
+		},
+		{
+			name:           "When level is unsupported",
+			level:          "trace",
+			expectedErr:    true,
+			expectedErrMsg: "unsupported log level: trace",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			level, err := ParseLevel(tc.level)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if level != tc.expectedLevel {
+					t.Errorf("unexpected level, expected %v but got %v", tc.expectedLevel, level)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSubsystemLevels(t *testing.T) {
+	testCases := []struct {
+		name           string
+		levels         string
+		expectedLevels map[string]slog.Level
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:           "When levels are not set",
+			levels:         "",
+			expectedLevels: map[string]slog.Level{},
+		},
+		{
+			name:   "When levels are set",
+			levels: "client=debug, manager=warn",
+			expectedLevels: map[string]slog.Level{
+				"client":  slog.LevelDebug,
+				"manager": slog.LevelWarn,
+			},
+		},
+		{
+			name:           "When subsystem is unsupported",
+			levels:         "scheduler=debug",
+			expectedErr:    true,
+			expectedErrMsg: "unsupported subsystem: scheduler",
+		},
+		{
+			name:           "When level is missing",
+			levels:         "client",
+			expectedErr:    true,
+			expectedErrMsg: "subsystem log level must be set as <subsystem>=<level>: client",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			levels, err := ParseSubsystemLevels(tc.levels)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if !tc.expectedErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(levels, tc.expectedLevels) {
+					t.Errorf("unexpected levels, expected %v but got %v", tc.expectedLevels, levels)
+				}
+			}
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	testCases := []struct {
+		name           string
+		format         string
+		expectedOutput *regexp.Regexp
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:           "When format is text",
+			format:         FormatText,
+			expectedOutput: regexp.MustCompile(`level=INFO source=logging_test.go:\d+ msg="CDI_DRA start"`),
+		},
+		{
+			name:           "When format is json",
+			format:         FormatJSON,
+			expectedOutput: regexp.MustCompile(`"level":"INFO","source":"logging_test.go:\d+","msg":"CDI_DRA start"`),
+		},
+		{
+			name:           "When format is unsupported",
+			format:         "yaml",
+			expectedErr:    true,
+			expectedErrMsg: "unsupported log format: yaml",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, Options{Format: tc.format, Level: slog.LevelInfo})
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			logger.Debug("not logged")
+			logger.Info("CDI_DRA start")
+			if strings.Contains(buf.String(), "not logged") {
+				t.Errorf("debug log is not filtered: %s", buf.String())
+			}
+			if !tc.expectedOutput.MatchString(buf.String()) {
+				t.Errorf("unexpected output: %s", buf.String())
+			}
+			if tc.format == FormatJSON {
+				var entry map[string]any
+				if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+					t.Errorf("output is not JSON: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSubsystemHandler(t *testing.T) {
+	// config.RandomString is in the config subsystem
+	configPC := reflect.ValueOf(config.RandomString).Pointer()
+	testCases := []struct {
+		name           string
+		pc             uintptr
+		level          slog.Level
+		expectedLogged bool
+	}{
+		{
+			name:           "When debug log of subsystem with debug level",
+			pc:             configPC,
+			level:          slog.LevelDebug,
+			expectedLogged: true,
+		},
+		{
+			name:           "When debug log of other package",
+			pc:             0,
+			level:          slog.LevelDebug,
+			expectedLogged: false,
+		},
+		{
+			name:           "When info log of other package",
+			pc:             0,
+			level:          slog.LevelInfo,
+			expectedLogged: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := NewLogger(&buf, Options{
+				Format:          FormatText,
+				Level:           slog.LevelInfo,
+				SubsystemLevels: map[string]slog.Level{"config": slog.LevelDebug},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			handler := logger.Handler()
+			if !handler.Enabled(context.Background(), tc.level) {
+				t.Fatalf("level %v must be enabled for the subsystem", tc.level)
+			}
+			if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), tc.level, "message", tc.pc)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if logged := buf.Len() > 0; logged != tc.expectedLogged {
+				t.Errorf("unexpected logged, expected %t but got %t: %s", tc.expectedLogged, logged, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in logs and errors
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are names of form fields, JSON fields and log attributes holding secrets
+var sensitiveKeys = []string{
+	"password", "client_secret", "access_token", "refresh_token", "id_token",
+	"token", "client_assertion", "authorization", "private_key", "client_key",
+}
+
+var (
+	// formFieldRegexp matches secrets in form bodies and queries, e.g. password=xxx
+	formFieldRegexp = regexp.MustCompile(`(?i)\b(` + strings.Join(sensitiveKeys, "|") + `)=[^&\s"]*`)
+	// jsonFieldRegexp matches secrets in JSON string fields, e.g. "refresh_token":"xxx"
+	jsonFieldRegexp = regexp.MustCompile(`(?i)"(` + strings.Join(sensitiveKeys, "|") + `)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+	// fieldRegexp matches secrets in structs and maps formatted by fmt, e.g. {ClientSecret:xxx} and map[password:xxx]
+	fieldRegexp = regexp.MustCompile(`(?i)\b(` + strings.ReplaceAll(strings.Join(sensitiveKeys, "|"), "_", "_?") + `):[^\s"&\]}]+`)
+	// bearerRegexp matches credentials in Authorization headers
+	bearerRegexp = regexp.MustCompile(`(?i)\b(Bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+	// basicRegexp matches candidates of Basic credentials, which are told from prose by isBasicCredentials
+	basicRegexp = regexp.MustCompile(`(?i)\b(Basic\s+)([A-Za-z0-9+/]+=*)`)
+	// jwtRegexp matches JWTs, e.g. access tokens and client assertions
+	jwtRegexp = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// pemRegexp matches private keys in PEM
+	pemRegexp = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// Redact masks tokens, passwords, client secrets, Authorization headers and private keys in s.
+func Redact(s string) string {
+	s = pemRegexp.ReplaceAllString(s, Redacted)
+	s = jsonFieldRegexp.ReplaceAllString(s, `"$1":"`+Redacted+`"`)
+	s = formFieldRegexp.ReplaceAllString(s, "$1="+Redacted)
+	s = fieldRegexp.ReplaceAllString(s, "$1:"+Redacted)
+	s = bearerRegexp.ReplaceAllString(s, "$1 "+Redacted)
+	s = basicRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		groups := basicRegexp.FindStringSubmatch(match)
+		if !isBasicCredentials(groups[2]) {
+			return match
+		}
+		return strings.TrimSpace(groups[1]) + " " + Redacted
+	})
+	s = jwtRegexp.ReplaceAllString(s, Redacted)
+	return s
+}
+
+// isBasicCredentials reports whether s looks like base64 of user:password rather than a word,
+// e.g. "Basic authentication failed" is left as is.
+func isBasicCredentials(s string) bool {
+	if len(s) < 8 || len(s)%4 != 0 {
+		return false
+	}
+	return strings.ContainsFunc(s, func(r rune) bool {
+		return r < 'a' || r > 'z'
+	})
+}
+
+// isSensitiveKey reports whether a log attribute of the key holds a secret.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if key == sensitiveKey {
+			return true
+		}
+	}
+	return false
+}
+
+// redactHandler redacts the message and attributes of every log before the underlying handler writes it.
+type redactHandler struct {
+	handler slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+	return &redactHandler{handler: h.handler.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{handler: h.handler.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	if isSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, 0, len(group))
+		for _, groupAttr := range group {
+			redacted = append(redacted, redactAttr(groupAttr))
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		// Other values such as slices, maps and structs are left to the handler, so that JSON keeps their structure.
+		// Secrets in them must be hidden by a LogValue method, whose result is resolved and walked above.
+		switch v := value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, Redact(v.Error()))
+		case []byte:
+			return slog.String(attr.Key, Redact(string(v)))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+const (
+	dryRunCreate = "create"
+	dryRunUpdate = "update"
+	dryRunDelete = "delete"
+)
+
+// dryRunReport is what one loop would have written to the cluster in dry-run mode.
+type dryRunReport struct {
+	Time           time.Time             `json:"time"`
+	ResourceSlices []resourceSliceChange `json:"resourceSlices"`
+	NodeLabels     []nodeLabelChange     `json:"nodeLabels"`
+}
+
+type resourceSliceChange struct {
+	Action         string `json:"action"`
+	Driver         string `json:"driver"`
+	Pool           string `json:"pool"`
+	Devices        int    `json:"devices"`
+	CurrentDevices int    `json:"currentDevices"`
+}
+
+type nodeLabelChange struct {
+	Action       string `json:"action"`
+	Node         string `json:"node"`
+	Label        string `json:"label"`
+	Value        string `json:"value,omitempty"`
+	CurrentValue string `json:"currentValue,omitempty"`
+}
+
+// addNodeLabels records the differences between the current and the desired labels of a node.
+func (r *dryRunReport) addNodeLabels(nodeName string, current, desired map[string]string) {
+	if r == nil {
+		return
+	}
+	for key, value := range desired {
+		currentValue, exist := current[key]
+		if !exist {
+			r.NodeLabels = append(r.NodeLabels, nodeLabelChange{Action: dryRunCreate, Node: nodeName, Label: key, Value: value})
+		} else if currentValue != value {
+			r.NodeLabels = append(r.NodeLabels, nodeLabelChange{Action: dryRunUpdate, Node: nodeName, Label: key, Value: value, CurrentValue: currentValue})
+		}
+	}
+	for key, currentValue := range current {
+		if _, exist := desired[key]; !exist {
+			r.NodeLabels = append(r.NodeLabels, nodeLabelChange{Action: dryRunDelete, Node: nodeName, Label: key, CurrentValue: currentValue})
+		}
+	}
+}
+
+// addResourceSlices records the differences between the ResourceSlices in the cluster and the pools
+// that the ResourceSlice controllers would publish.
+func (r *dryRunReport) addResourceSlices(ctx context.Context, m *CDIManager) error {
+	resourceSlices, err := m.coreClient.ResourceV1().ResourceSlices().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ResourceSlices: %w", err)
+	}
+	for driverName, driverResources := range m.namedDriverResources {
+		currentDevices := make(map[string][]string)
+		for _, resourceSlice := range resourceSlices.Items {
+			if resourceSlice.Spec.Driver != driverName {
+				continue
+			}
+			poolName := resourceSlice.Spec.Pool.Name
+			for _, device := range resourceSlice.Spec.Devices {
+				currentDevices[poolName] = append(currentDevices[poolName], device.Name)
+			}
+			if _, exist := currentDevices[poolName]; !exist {
+				currentDevices[poolName] = []string{}
+			}
+		}
+		for poolName, pool := range driverResources.Pools {
+			devices := poolDeviceNames(pool)
+			current, exist := currentDevices[poolName]
+			if !exist {
+				r.ResourceSlices = append(r.ResourceSlices, resourceSliceChange{Action: dryRunCreate, Driver: driverName, Pool: poolName, Devices: len(devices)})
+				continue
+			}
+			slices.Sort(current)
+			if !slices.Equal(current, devices) {
+				r.ResourceSlices = append(r.ResourceSlices, resourceSliceChange{Action: dryRunUpdate, Driver: driverName, Pool: poolName, Devices: len(devices), CurrentDevices: len(current)})
+			}
+		}
+		// The controller removes the pools which it does not publish
+		for poolName, current := range currentDevices {
+			if _, exist := driverResources.Pools[poolName]; !exist {
+				r.ResourceSlices = append(r.ResourceSlices, resourceSliceChange{Action: dryRunDelete, Driver: driverName, Pool: poolName, CurrentDevices: len(current)})
+			}
+		}
+	}
+	return nil
+}
+
+func poolDeviceNames(pool resourceslice.Pool) []string {
+	names := []string{}
+	for _, slice := range pool.Slices {
+		for _, device := range slice.Devices {
+			names = append(names, device.Name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (r *dryRunReport) sort() {
+	slices.SortFunc(r.ResourceSlices, func(a, b resourceSliceChange) int {
+		return strings.Compare(a.Driver+"/"+a.Pool, b.Driver+"/"+b.Pool)
+	})
+	slices.SortFunc(r.NodeLabels, func(a, b nodeLabelChange) int {
+		return strings.Compare(a.Node+"/"+a.Label, b.Node+"/"+b.Label)
+	})
+}
+
+func (r *dryRunReport) log() {
+	for _, c := range r.ResourceSlices {
+		slog.Info("dry-run: ResourceSlice would be changed", "action", c.Action, "driver", c.Driver, "poolName", c.Pool, "devices", c.Devices, "currentDevices", c.CurrentDevices)
+	}
+	for _, c := range r.NodeLabels {
+		slog.Info("dry-run: node label would be changed", "action", c.Action, "nodeName", c.Node, "label", c.Label, "value", c.Value, "currentValue", c.CurrentValue)
+	}
+	slog.Info("dry-run: loop result", "resourceSliceChanges", len(r.ResourceSlices), "nodeLabelChanges", len(r.NodeLabels))
+}
+
+// write replaces the JSON report at path, so that a reader never sees a partially written file.
+func (r *dryRunReport) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write dry-run report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write dry-run report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write dry-run report: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write dry-run report: %w", err)
+	}
+	return nil
+}
+
+// reportDryRunPools reports the ResourceSlices again after pools are updated between the loops, e.g. on FM events.
+// The node label changes of the latest loop are kept, since node labels are updated only by the loop.
+func (m *CDIManager) reportDryRunPools(ctx context.Context) error {
+	if m.dryRunReport == nil {
+		m.dryRunReport = &dryRunReport{}
+	}
+	m.dryRunReport.Time = time.Now()
+	m.dryRunReport.ResourceSlices = nil
+	return m.reportDryRun(ctx)
+}
+
+// reportDryRun logs the changes of the loop and writes them to the JSON report if configured.
+func (m *CDIManager) reportDryRun(ctx context.Context) error {
+	report := m.dryRunReport
+	if err := report.addResourceSlices(ctx, m); err != nil {
+		return err
+	}
+	report.sort()
+	report.log()
+	if len(m.cdiOptions.dryRunReport) > 0 {
+		return report.write(m.cdiOptions.dryRunReport)
+	}
+	return nil
+}
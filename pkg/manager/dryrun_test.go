@@ -0,0 +1,220 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCheckResourcePoolLoopDryRun(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		resourceSlices        []*resourceapi.ResourceSlice
+		expectedSliceActions  map[string]string
+		expectedCurrentDevice map[string]int
+	}{
+		{
+			name: "When no ResourceSlice is published",
+			expectedSliceActions: map[string]string{
+				"test-device-1-fabric1": dryRunCreate,
+				"test-device-3-fabric3": dryRunCreate,
+			},
+		},
+		{
+			name: "When ResourceSlices are published",
+			resourceSlices: []*resourceapi.ResourceSlice{
+				createTestResourceSlice("test-driver-1", "test-device-1-fabric1", "test-device-1-0"),
+				createTestResourceSlice("test-driver-1", "test-device-2-fabric1", "test-device-2-0", "test-device-2-1"),
+				createTestResourceSlice("test-driver-2", "test-device-3-fabric4", "test-device-3-0"),
+			},
+			expectedSliceActions: map[string]string{
+				"test-device-1-fabric1": dryRunUpdate,
+				"test-device-2-fabric1": "",
+				"test-device-3-fabric3": dryRunCreate,
+				"test-device-3-fabric4": dryRunDelete,
+			},
+			expectedCurrentDevice: map[string]int{
+				"test-device-1-fabric1": 1,
+				"test-device-3-fabric4": 1,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				UseCapiBmh:         false,
+				UseCM:              false,
+				DRAenabled:         true,
+				CaseDriverResource: CaseDriverResourceEmpty,
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+			reportPath := filepath.Join(t.TempDir(), "report.json")
+			m.cdiOptions.dryRun = true
+			m.cdiOptions.dryRunReport = reportPath
+
+			for _, resourceSlice := range tc.resourceSlices {
+				if _, err := m.coreClient.ResourceV1().ResourceSlices().Create(context.Background(), resourceSlice, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to create ResourceSlice: %v", err)
+				}
+			}
+
+			// The second loop must report the same changes, since nothing is written
+			for i := 0; i < 2; i++ {
+				if err := m.startCheckResourcePoolLoop(context.Background(), nil); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				data, err := os.ReadFile(reportPath)
+				if err != nil {
+					t.Fatalf("failed to read report: %v", err)
+				}
+				var report dryRunReport
+				if err := json.Unmarshal(data, &report); err != nil {
+					t.Fatalf("failed to parse report: %v", err)
+				}
+				sliceActions := make(map[string]resourceSliceChange)
+				for _, change := range report.ResourceSlices {
+					sliceActions[change.Pool] = change
+				}
+				for poolName, expectedAction := range tc.expectedSliceActions {
+					change := sliceActions[poolName]
+					if change.Action != expectedAction {
+						t.Errorf("unexpected action of pool %s, expected %q but got %q", poolName, expectedAction, change.Action)
+					}
+					if change.CurrentDevices != tc.expectedCurrentDevice[poolName] {
+						t.Errorf("unexpected current devices of pool %s, expected %d but got %d", poolName, tc.expectedCurrentDevice[poolName], change.CurrentDevices)
+					}
+				}
+				var labelFound bool
+				for _, change := range report.NodeLabels {
+					if change.Node == "test-node-0" && change.Label == "cohdi.com/fabric" {
+						labelFound = true
+						if change.Action != dryRunCreate || change.Value != "1" {
+							t.Errorf("unexpected change of fabric label: %+v", change)
+						}
+					}
+				}
+				if !labelFound {
+					t.Error("change of fabric label is not reported")
+				}
+			}
+
+			resourceSlices, err := m.coreClient.ResourceV1().ResourceSlices().List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error in kube client List: %v", err)
+			}
+			if len(resourceSlices.Items) != len(tc.resourceSlices) {
+				t.Errorf("unexpected ResourceSlice num, expected %d but got %d", len(tc.resourceSlices), len(resourceSlices.Items))
+			}
+			node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), "test-node-0", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("not found node: %v", err)
+			}
+			if fabric, exist := node.Labels["cohdi.com/fabric"]; exist {
+				t.Errorf("unexpected label of fabric id in dry-run: %s", fabric)
+			}
+		})
+	}
+}
+
+func createTestResourceSlice(driverName string, poolName string, deviceNames ...string) *resourceapi.ResourceSlice {
+	resourceSlice := &resourceapi.ResourceSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: poolName,
+		},
+		Spec: resourceapi.ResourceSliceSpec{
+			Driver: driverName,
+			Pool: resourceapi.ResourcePool{
+				Name:               poolName,
+				ResourceSliceCount: 1,
+			},
+		},
+	}
+	for _, deviceName := range deviceNames {
+		resourceSlice.Spec.Devices = append(resourceSlice.Spec.Devices, resourceapi.Device{Name: deviceName})
+	}
+	return resourceSlice
+}
+
+func TestCheckResourcePoolLoopDryRunRemoveTenantLabels(t *testing.T) {
+	testSpec := config.TestSpec{
+		DRAenabled:         true,
+		CaseDriverResource: CaseDriverResourceEmpty,
+		TenantID:           "00000000-0000-0001-0000-000000000000",
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	m.cdiOptions.dryRun = true
+	m.cdiOptions.dryRunReport = reportPath
+
+	m.tenants = []*tenant{
+		{
+			name:         "tenant-a",
+			cdiClient:    m.cdiClient,
+			nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant": "a"}),
+		},
+	}
+	setTestNodeLabel(t, m, "test-node-0", "example.com/tenant", "a")
+	// test-node-1 is left labeled by a tenant which no longer claims it
+	setTestNodeLabel(t, m, "test-node-1", "cohdi.com/tenant", "tenant-b")
+	time.Sleep(1 * time.Second)
+
+	if err := m.startCheckResourcePoolLoop(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var report dryRunReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("failed to parse report: %v", err)
+	}
+	var removalFound bool
+	for _, change := range report.NodeLabels {
+		if change.Node == "test-node-1" && change.Label == "cohdi.com/tenant" {
+			removalFound = true
+			if change.Action != dryRunDelete || change.CurrentValue != "tenant-b" {
+				t.Errorf("unexpected change of tenant label: %+v", change)
+			}
+		}
+	}
+	if !removalFound {
+		t.Error("removal of tenant label is not reported")
+	}
+
+	node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), "test-node-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("not found node: %v", err)
+	}
+	if node.Labels["cohdi.com/tenant"] != "tenant-b" {
+		t.Errorf("label of tenant is removed in dry-run: %v", node.Labels)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/client"
+	"cdi_dra/pkg/tracing"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+	"k8s.io/utils/clock"
+)
+
+// The reconnection to FM backs off exponentially with jitter, so that drivers do not reconnect at once after FM restarts.
+// The backoff is reset once a stream stays open for fmEventReconnectReset.
+const (
+	fmEventReconnectInitial = 1 * time.Second
+	fmEventReconnectMax     = 5 * time.Minute
+	fmEventReconnectReset   = 10 * time.Minute
+	fmEventReconnectFactor  = 2.0
+	fmEventReconnectJitter  = 1.0
+)
+
+// fabricQueue coalesces the fabrics notified by FM, so that a burst of events fetches each fabric once.
+type fabricQueue struct {
+	mutex  sync.Mutex
+	keys   map[fabricKey]bool
+	notify chan struct{}
+}
+
+func newFabricQueue() *fabricQueue {
+	return &fabricQueue{
+		keys:   make(map[fabricKey]bool),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (q *fabricQueue) add(key fabricKey) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.keys[key] = true
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *fabricQueue) take() []fabricKey {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	var keys []fabricKey
+	for key := range q.keys {
+		keys = append(keys, key)
+	}
+	q.keys = make(map[fabricKey]bool)
+	return keys
+}
+
+// watchFMEvents subscribes to FM events of every tenant and updates the pools of the notified fabrics until ctx is done.
+// The update in flight runs with workCtx, so that it may finish within the grace period after ctx is done.
+// A broken subscription is resumed from the last event, and the loop still polls every fabric as a safety net.
+func (m *CDIManager) watchFMEvents(ctx context.Context, workCtx context.Context, controllers map[string]*resourceslice.Controller) {
+	queue := newFabricQueue()
+	var wg sync.WaitGroup
+	for _, t := range m.getTenants() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var lastEventID string
+			backoff := wait.NewExponentialBackoffManager(fmEventReconnectInitial, fmEventReconnectMax, fmEventReconnectReset, fmEventReconnectFactor, fmEventReconnectJitter, clock.RealClock{})
+			wait.BackoffUntil(func() {
+				var err error
+				lastEventID, err = t.cdiClient.WatchFMEvents(ctx, lastEventID, func(event client.FMEvent) {
+					m.handleFMEvent(t, event, queue)
+				})
+				if ctx.Err() == nil {
+					slog.Warn("FM event stream is closed, reconnecting", "tenant", t.name, "error", err)
+				}
+			}, backoff, true, ctx.Done())
+		}()
+	}
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-queue.notify:
+		}
+		m.mutex.Lock()
+		eventCtx, span := tracing.Start(workCtx, "refreshNotifiedFabrics")
+		err := m.refreshFabrics(eventCtx, m.fabricScheduler.lookup(queue.take()), controllers, false)
+		tracing.End(span, err)
+		m.mutex.Unlock()
+		if err != nil {
+			slog.Error("Failed to refresh fabrics notified by FM", "error", err)
+		}
+	}
+}
+
+func (m *CDIManager) handleFMEvent(t *tenant, event client.FMEvent, queue *fabricQueue) {
+	if event.Type != client.FMEventReservedResourcesChanged {
+		return
+	}
+	if event.FabricID == nil {
+		slog.Warn("FM event without fabric id is ignored", "tenant", t.name, "eventID", event.ID, "machineUUID", event.MachineUUID)
+		return
+	}
+	slog.Debug("FM event received", "tenant", t.name, "eventID", event.ID, "fabricID", *event.FabricID, "machineUUID", event.MachineUUID)
+	queue.add(fabricKey{tenant: t.name, fabricID: *event.FabricID})
+}
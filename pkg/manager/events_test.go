@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatchFMEvents(t *testing.T) {
+	testSpec := config.TestSpec{
+		UseCapiBmh:         false,
+		UseCM:              false,
+		DRAenabled:         true,
+		CaseDriverResource: CaseDriverResourceEmpty,
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+	m.fabricScheduler = newFabricScheduler(time.Minute, time.Minute)
+	controllers := createTestResourceSliceControllers(t, m.coreClient)
+
+	if err := m.startCheckResourcePoolLoop(context.Background(), controllers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The stub FM notifies a change in fabric 1 only, so only its pool is restored
+	pools := m.namedDriverResources["test-driver-1"].Pools
+	delete(pools, "test-device-1-fabric1")
+	delete(pools, "test-device-1-fabric2")
+
+	notifiedKey := fabricKey{tenant: m.getTenants()[0].name, fabricID: 1}
+	next := m.fabricScheduler.fabrics[notifiedKey].next
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.watchFMEvents(ctx, ctx, controllers)
+	}()
+
+	poolExists := func(poolName string) bool {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		_, exist := pools[poolName]
+		return exist
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !poolExists("test-device-1-fabric1") {
+		if time.Now().After(deadline) {
+			t.Fatal("pool of the notified fabric is not updated")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if poolExists("test-device-1-fabric2") {
+		t.Error("pool of the fabric which is not notified is updated")
+	}
+	m.mutex.Lock()
+	if notifiedNext := m.fabricScheduler.fabrics[notifiedKey].next; !notifiedNext.Equal(next) {
+		t.Errorf("next poll of the notified fabric is moved from %v to %v", next, notifiedNext)
+	}
+	m.mutex.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Error("FM event watcher does not stop")
+	}
+}
+
+func TestRefreshNotifiedFabricsDryRun(t *testing.T) {
+	testSpec := config.TestSpec{
+		UseCapiBmh:         false,
+		UseCM:              false,
+		DRAenabled:         true,
+		CaseDriverResource: CaseDriverResourceEmpty,
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+	m.cdiOptions.dryRun = true
+	m.fabricScheduler = newFabricScheduler(time.Minute, time.Minute)
+	controllers := createTestResourceSliceControllers(t, m.coreClient)
+
+	if err := m.startCheckResourcePoolLoop(context.Background(), controllers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	loopReport := *m.dryRunReport
+	delete(m.namedDriverResources["test-driver-1"].Pools, "test-device-1-fabric1")
+
+	notifiedKey := fabricKey{tenant: m.getTenants()[0].name, fabricID: 1}
+	if err := m.refreshFabrics(context.Background(), m.fabricScheduler.lookup([]fabricKey{notifiedKey}), controllers, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report := m.dryRunReport
+	if !report.Time.After(loopReport.Time) {
+		t.Errorf("time of report is not updated, %v is not after %v", report.Time, loopReport.Time)
+	}
+	var actions []string
+	for _, change := range report.ResourceSlices {
+		if change.Driver == "test-driver-1" && change.Pool == "test-device-1-fabric1" {
+			actions = append(actions, change.Action)
+		}
+	}
+	if !slices.Equal(actions, []string{dryRunCreate}) {
+		t.Errorf("unexpected actions of the notified pool, expected %v but got %v", []string{dryRunCreate}, actions)
+	}
+	if len(report.NodeLabels) != len(loopReport.NodeLabels) {
+		t.Errorf("node label changes of the loop are not kept, expected %d but got %d", len(loopReport.NodeLabels), len(report.NodeLabels))
+	}
+	resourceSlices, err := m.coreClient.ResourceV1().ResourceSlices().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list ResourceSlices: %v", err)
+	}
+	if len(resourceSlices.Items) != 0 {
+		t.Errorf("ResourceSlices are written in dry-run mode: %d", len(resourceSlices.Items))
+	}
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	InspectMachines   = "machines"
+	InspectFabrics    = "fabrics"
+	InspectNodeGroups = "nodegroups"
+	InspectPools      = "pools"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+var (
+	InspectTargets = []string{InspectMachines, InspectFabrics, InspectNodeGroups, InspectPools}
+	Outputs        = []string{OutputTable, OutputJSON}
+)
+
+type machineView struct {
+	Tenant      string `json:"tenant,omitempty"`
+	NodeName    string `json:"nodeName"`
+	MachineUUID string `json:"machineUUID"`
+	FabricID    *int   `json:"fabricID"`
+}
+
+type fabricView struct {
+	Tenant   string   `json:"tenant,omitempty"`
+	FabricID int      `json:"fabricID"`
+	Machines int      `json:"machines"`
+	Nodes    []string `json:"nodes"`
+}
+
+type nodeGroupView struct {
+	Tenant  string            `json:"tenant,omitempty"`
+	Name    string            `json:"name"`
+	UUID    string            `json:"uuid"`
+	Nodes   []string          `json:"nodes"`
+	Devices []deviceLimitView `json:"devices"`
+}
+
+type deviceLimitView struct {
+	Model string `json:"model"`
+	Min   *int   `json:"min"`
+	Max   *int   `json:"max"`
+}
+
+type poolView struct {
+	Tenant    string   `json:"tenant,omitempty"`
+	Pool      string   `json:"pool"`
+	Driver    string   `json:"driver"`
+	Model     string   `json:"model"`
+	FabricID  int      `json:"fabricID"`
+	Available int      `json:"available"`
+	Nodes     []string `json:"nodes"`
+}
+
+// inspectView is the result of an inspection, printed as a table or as JSON.
+type inspectView struct {
+	header []string
+	rows   [][]string
+	items  any
+}
+
+// Inspect prints the view of CDI for the target once, for troubleshooting without reading debug logs.
+func Inspect(ctx context.Context, cfg *config.Config, target string, output string, w io.Writer) error {
+	m, err := newCDIManager(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer m.revokeTokens()
+
+	view, err := m.inspect(ctx, target)
+	if err != nil {
+		return err
+	}
+	return view.write(w, output)
+}
+
+func (m *CDIManager) inspect(ctx context.Context, target string) (*inspectView, error) {
+	switch target {
+	case InspectMachines:
+		return m.inspectMachines(ctx)
+	case InspectFabrics:
+		return m.inspectFabrics(ctx)
+	case InspectNodeGroups:
+		return m.inspectNodeGroups(ctx)
+	case InspectPools:
+		return m.inspectPools(ctx)
+	}
+	return nil, fmt.Errorf("inspect target must be one of %v", InspectTargets)
+}
+
+func (m *CDIManager) inspectMachines(ctx context.Context) (*inspectView, error) {
+	muuids, err := m.getMachineUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	machines, err := m.inspectTenantMachines(ctx, muuids)
+	if err != nil {
+		return nil, err
+	}
+	items := []machineView{}
+	found := make(map[string]bool)
+	for _, machine := range machines {
+		items = append(items, machineView{Tenant: machine.tenant, NodeName: machine.nodeName, MachineUUID: machine.machineUUID, FabricID: machine.fabricID})
+		found[machine.nodeName] = true
+	}
+	// Nodes which no tenant processes are listed without tenant and fabric
+	for nodeName, muuid := range muuids {
+		if !found[nodeName] {
+			items = append(items, machineView{NodeName: nodeName, MachineUUID: muuid})
+		}
+	}
+	slices.SortFunc(items, func(a, b machineView) int {
+		return strings.Compare(a.Tenant+"/"+a.NodeName, b.Tenant+"/"+b.NodeName)
+	})
+
+	view := &inspectView{header: []string{"TENANT", "NODE", "MACHINE UUID", "FABRIC"}, items: items}
+	for _, item := range items {
+		view.rows = append(view.rows, []string{item.Tenant, item.NodeName, item.MachineUUID, safeReference(item.FabricID)})
+	}
+	return view, nil
+}
+
+// inspectTenantMachines returns the machines of every tenant in the same way as the resource pool loop.
+func (m *CDIManager) inspectTenantMachines(ctx context.Context, muuids map[string]string) ([]*machine, error) {
+	var machines []*machine
+	for _, t := range m.getTenants() {
+		tenantMachines, err := m.getTenantMachines(ctx, t, muuids)
+		if err != nil {
+			return nil, m.tenantError(t, err)
+		}
+		machines = append(machines, tenantMachines...)
+	}
+	if m.isMultiTenant() {
+		machines = excludeSharedNodes(machines)
+	}
+	return machines, nil
+}
+
+func (m *CDIManager) inspectFabrics(ctx context.Context) (*inspectView, error) {
+	muuids, err := m.getMachineUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]string)
+	for nodeName, muuid := range muuids {
+		nodeNames[muuid] = nodeName
+	}
+	items := []fabricView{}
+	for _, t := range m.getTenants() {
+		mList, err := m.getMachineList(ctx, t)
+		if err != nil {
+			return nil, m.tenantError(t, err)
+		}
+		fabrics := make(map[int]*fabricView)
+		for _, fmMachine := range mList.Data.Machines {
+			if fmMachine.FabricID == nil {
+				continue
+			}
+			fabric, exist := fabrics[*fmMachine.FabricID]
+			if !exist {
+				fabric = &fabricView{Tenant: t.name, FabricID: *fmMachine.FabricID, Nodes: []string{}}
+				fabrics[*fmMachine.FabricID] = fabric
+			}
+			fabric.Machines++
+			// Machines out of this cluster are only counted
+			if nodeName, exist := nodeNames[fmMachine.MachineUUID]; exist {
+				fabric.Nodes = append(fabric.Nodes, nodeName)
+			}
+		}
+		for _, fabric := range fabrics {
+			slices.Sort(fabric.Nodes)
+			items = append(items, *fabric)
+		}
+	}
+	slices.SortFunc(items, func(a, b fabricView) int {
+		if c := strings.Compare(a.Tenant, b.Tenant); c != 0 {
+			return c
+		}
+		return a.FabricID - b.FabricID
+	})
+
+	view := &inspectView{header: []string{"TENANT", "FABRIC", "MACHINES", "NODES"}, items: items}
+	for _, item := range items {
+		view.rows = append(view.rows, []string{item.Tenant, strconv.Itoa(item.FabricID), strconv.Itoa(item.Machines), strings.Join(item.Nodes, ",")})
+	}
+	return view, nil
+}
+
+func (m *CDIManager) inspectNodeGroups(ctx context.Context) (*inspectView, error) {
+	if !m.cdiOptions.useCM {
+		return nil, fmt.Errorf("node groups can be inspected only when USE_CM is true")
+	}
+	muuids, err := m.getMachineUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[string]string)
+	for nodeName, muuid := range muuids {
+		nodeNames[muuid] = nodeName
+	}
+	items := []nodeGroupView{}
+	for _, t := range m.getTenants() {
+		nodeGroups, err := m.getNodeGroups(ctx, t)
+		if err != nil {
+			return nil, m.tenantError(t, err)
+		}
+		for _, nodeGroup := range nodeGroups.NodeGroups {
+			ngInfo, err := m.getNodeGroupInfo(ctx, t, nodeGroup)
+			if err != nil {
+				return nil, m.tenantError(t, err)
+			}
+			item := nodeGroupView{Tenant: t.name, Name: ngInfo.Name, UUID: ngInfo.UUID, Nodes: []string{}, Devices: []deviceLimitView{}}
+			for _, muuid := range ngInfo.MachineIDs {
+				if nodeName, exist := nodeNames[muuid]; exist {
+					item.Nodes = append(item.Nodes, nodeName)
+				}
+			}
+			slices.Sort(item.Nodes)
+			// Min and max are the same in a node group, so they are got from any machine
+			if len(ngInfo.MachineIDs) > 0 {
+				for _, deviceInfo := range m.deviceInfos {
+					min, max, err := m.getMinMaxNums(ctx, t, ngInfo.MachineIDs[0], deviceInfo.CDIModelName)
+					if err != nil {
+						return nil, m.tenantError(t, err)
+					}
+					item.Devices = append(item.Devices, deviceLimitView{Model: deviceInfo.CDIModelName, Min: min, Max: max})
+				}
+			}
+			items = append(items, item)
+		}
+	}
+
+	view := &inspectView{header: []string{"TENANT", "NODE GROUP", "UUID", "MODEL", "MIN", "MAX", "NODES"}, items: items}
+	for _, item := range items {
+		if len(item.Devices) == 0 {
+			view.rows = append(view.rows, []string{item.Tenant, item.Name, item.UUID, "", "", "", strings.Join(item.Nodes, ",")})
+		}
+		for _, device := range item.Devices {
+			view.rows = append(view.rows, []string{item.Tenant, item.Name, item.UUID, device.Model, safeReference(device.Min), safeReference(device.Max), strings.Join(item.Nodes, ",")})
+		}
+	}
+	return view, nil
+}
+
+func (m *CDIManager) inspectPools(ctx context.Context) (*inspectView, error) {
+	muuids, err := m.getMachineUUIDs()
+	if err != nil {
+		return nil, err
+	}
+	machines, err := m.inspectTenantMachines(ctx, muuids)
+	if err != nil {
+		return nil, err
+	}
+	pools := make(map[string]*poolView)
+	for _, machine := range machines {
+		for model, device := range machine.deviceList {
+			poolName := getPoolName(device, machine.tenant, *machine.fabricID)
+			pool, exist := pools[poolName]
+			if !exist {
+				pool = &poolView{Tenant: machine.tenant, Pool: poolName, Driver: device.driverName, Model: model, FabricID: *machine.fabricID, Available: device.availableDeviceCount}
+				pools[poolName] = pool
+			}
+			pool.Nodes = append(pool.Nodes, machine.nodeName)
+		}
+	}
+	items := []poolView{}
+	for _, pool := range pools {
+		slices.Sort(pool.Nodes)
+		items = append(items, *pool)
+	}
+	slices.SortFunc(items, func(a, b poolView) int {
+		return strings.Compare(a.Pool, b.Pool)
+	})
+
+	view := &inspectView{header: []string{"TENANT", "POOL", "DRIVER", "MODEL", "FABRIC", "AVAILABLE", "NODES"}, items: items}
+	for _, item := range items {
+		view.rows = append(view.rows, []string{item.Tenant, item.Pool, item.Driver, item.Model, strconv.Itoa(item.FabricID), strconv.Itoa(item.Available), strings.Join(item.Nodes, ",")})
+	}
+	return view, nil
+}
+
+func (m *CDIManager) tenantError(t *tenant, err error) error {
+	if m.isMultiTenant() {
+		return fmt.Errorf("tenant %s: %w", t.name, err)
+	}
+	return err
+}
+
+func (v *inspectView) write(w io.Writer, output string) error {
+	switch output {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v.items)
+	case OutputTable, "":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(v.header, "\t"))
+		for _, row := range v.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("output must be one of %v", Outputs)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"bytes"
+	"cdi_dra/pkg/config"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCDIManagerInspect(t *testing.T) {
+	testCases := []struct {
+		name           string
+		target         string
+		useCM          bool
+		expectedItems  int
+		expectedHeader string
+		expectedRow    []string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:           "When machines are inspected",
+			target:         InspectMachines,
+			expectedItems:  9,
+			expectedHeader: "MACHINE UUID",
+			expectedRow:    []string{"test-node-0", "1"},
+		},
+		{
+			name:           "When fabrics are inspected",
+			target:         InspectFabrics,
+			expectedItems:  3,
+			expectedHeader: "FABRIC",
+			expectedRow:    []string{"1", "test-node-0,test-node-3,test-node-6"},
+		},
+		{
+			name:           "When node groups are inspected",
+			target:         InspectNodeGroups,
+			useCM:          true,
+			expectedItems:  3,
+			expectedHeader: "NODE GROUP",
+			expectedRow:    []string{"NodeGroup1", "DEVICE 1", "1", "3", "test-node-0,test-node-1,test-node-2"},
+		},
+		{
+			name:           "When node groups are inspected with USE_CM is false",
+			target:         InspectNodeGroups,
+			useCM:          false,
+			expectedErr:    true,
+			expectedErrMsg: "node groups can be inspected only when USE_CM is true",
+		},
+		{
+			name:           "When pools are inspected",
+			target:         InspectPools,
+			expectedItems:  9,
+			expectedHeader: "AVAILABLE",
+			expectedRow:    []string{"test-device-1-fabric1", "test-driver-1", "DEVICE 1", "1", "2", "test-node-0,test-node-3,test-node-6"},
+		},
+		{
+			name:           "When target is unknown",
+			target:         "devices",
+			expectedErr:    true,
+			expectedErrMsg: "inspect target must be one of",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				UseCapiBmh: false,
+				UseCM:      tc.useCM,
+				DRAenabled: true,
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+
+			view, err := m.inspect(context.Background(), tc.target)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var table bytes.Buffer
+			if err := view.write(&table, OutputTable); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			lines := strings.Split(strings.TrimSpace(table.String()), "\n")
+			if !strings.Contains(lines[0], tc.expectedHeader) {
+				t.Errorf("unexpected header, expected %s but got %s", tc.expectedHeader, lines[0])
+			}
+			var rowFound bool
+			for _, line := range lines[1:] {
+				if containsInOrder(line, tc.expectedRow) {
+					rowFound = true
+				}
+			}
+			if !rowFound {
+				t.Errorf("expected row %v is not found in\n%s", tc.expectedRow, table.String())
+			}
+
+			var out bytes.Buffer
+			if err := view.write(&out, OutputJSON); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var items []map[string]any
+			if err := json.Unmarshal(out.Bytes(), &items); err != nil {
+				t.Fatalf("failed to parse JSON output: %v", err)
+			}
+			if len(items) != tc.expectedItems {
+				t.Errorf("unexpected item num, expected %d but got %d", tc.expectedItems, len(items))
+			}
+		})
+	}
+}
+
+// containsInOrder reports whether the columns of the table row include the expected values in order.
+func containsInOrder(line string, expected []string) bool {
+	columns := regexp.MustCompile(`\s{2,}`).Split(strings.TrimSpace(line), -1)
+	i := 0
+	for _, column := range columns {
+		if i < len(expected) && column == expected[i] {
+			i++
+		}
+	}
+	return i == len(expected)
+}
+
+func TestCDIManagerInspectMachinesMultiTenant(t *testing.T) {
+	testSpec := config.TestSpec{
+		DRAenabled: true,
+		TenantID:   "00000000-0000-0002-0000-000000000000",
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+
+	// Both tenants see every machine in FM, so that a node can be claimed by both of them
+	m.tenants = []*tenant{
+		{
+			name:         "tenant-a",
+			cdiClient:    m.cdiClient,
+			nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant-a": "true"}),
+		},
+		{
+			name:         "tenant-b",
+			cdiClient:    m.cdiClient,
+			nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant-b": "true"}),
+		},
+	}
+	setTestNodeLabel(t, m, "test-node-0", "example.com/tenant-a", "true")
+	setTestNodeLabel(t, m, "test-node-1", "example.com/tenant-a", "true")
+	setTestNodeLabel(t, m, "test-node-1", "example.com/tenant-b", "true")
+	setTestNodeLabel(t, m, "test-node-2", "example.com/tenant-b", "true")
+	time.Sleep(1 * time.Second)
+
+	view, err := m.inspect(context.Background(), InspectMachines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tenants := make(map[string]string)
+	for _, item := range view.items.([]machineView) {
+		if _, exist := tenants[item.NodeName]; exist {
+			t.Errorf("node %s is listed more than once", item.NodeName)
+		}
+		tenants[item.NodeName] = item.Tenant
+	}
+	// The node shared by tenants is processed by no tenant
+	expectedTenants := map[string]string{
+		"test-node-0": "tenant-a",
+		"test-node-1": "",
+		"test-node-2": "tenant-b",
+		"test-node-3": "",
+	}
+	for nodeName, expectedTenant := range expectedTenants {
+		if tenant, exist := tenants[nodeName]; !exist || tenant != expectedTenant {
+			t.Errorf("unexpected tenant of %s, expected %q but got %q", nodeName, expectedTenant, tenant)
+		}
+	}
+}
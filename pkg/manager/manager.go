@@ -20,14 +20,21 @@ import (
 	"cdi_dra/pkg/client"
 	"cdi_dra/pkg/config"
 	"cdi_dra/pkg/kube_utils"
+	"cdi_dra/pkg/tracing"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"strconv"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
@@ -37,9 +44,7 @@ import (
 	"k8s.io/utils/strings/slices"
 )
 
-const (
-	configMapName = "composable-dra/composable-dra-dds"
-)
+const revokeTimeOut = 5 * time.Second
 
 type CDIManager struct {
 	coreClient           kube_client.Interface
@@ -49,16 +54,36 @@ type CDIManager struct {
 	deviceInfos          []config.DeviceInfo
 	labelPrefix          string
 	cdiClient            *client.CDIClient
+	tenants              []*tenant
 	kubecontrollers      *kube_utils.KubeControllers
 	cdiOptions           CDIOptions
+	dryRunReport         *dryRunReport
+	fabricScheduler      *fabricScheduler
+	// mutex serializes the loop and the updates triggered by FM events
+	mutex sync.Mutex
+}
+
+// tenant is a set of nodes whose devices are managed by one CDI tenant.
+// In single-tenant mode, the only tenant has no name and owns all nodes.
+type tenant struct {
+	name         string
+	cdiClient    *client.CDIClient
+	nodeSelector labels.Selector
+	nodeGroups   []string
 }
 
 type CDIOptions struct {
 	useCapiBmh bool
 	useCM      bool
+	// dryRun computes pools and labels without writing them to the cluster
+	dryRun       bool
+	dryRunReport string
+	// withdrawPools deletes the published pools on shutdown
+	withdrawPools bool
 }
 
 type machine struct {
+	tenant        string
 	nodeName      string
 	machineUUID   string
 	fabricID      *int
@@ -78,64 +103,153 @@ type device struct {
 }
 
 func StartCDIManager(ctx context.Context, cfg *config.Config) error {
-	kconfig, err := kube_utils.NewClientConfig()
+	// The loop in flight is not interrupted on shutdown until the grace period is over
+	workCtx, cancelWork := withGracePeriod(ctx, cfg.ShutdownGracePeriod)
+	defer cancelWork()
+
+	m, err := newCDIManager(workCtx, cfg)
 	if err != nil {
 		return err
 	}
 
+	for _, t := range m.getTenants() {
+		if err := t.cdiClient.WatchCredentials(workCtx); err != nil {
+			slog.Error("Failed to watch credentials", "tenant", t.name, "error", err)
+			return err
+		}
+		// Renew IM tokens in background so that the loop does not wait for ID manager
+		go t.cdiClient.RunTokenRefresher(workCtx)
+	}
+
+	// ResourceSlice controllers are not started in dry-run mode,
+	// since they would delete the ResourceSlices published before
+	var controllers map[string]*resourceslice.Controller
+	if cfg.DryRun {
+		if !kube_utils.IsDRAEnabled(m.discoveryClient) {
+			return fmt.Errorf("not enabled feature gate of Dynamic Resource Allocation")
+		}
+		slog.Warn("dry-run mode: ResourceSlices and node labels are not updated")
+	} else {
+		controllers, err = m.startResourceSliceController(workCtx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// With per-fabric polling, the loop wakes up at the minimum fabric interval
+	// and checks only the fabrics which are due until the scan interval passes
+	period := cfg.ScanInterval
+	if m.fabricScheduler != nil {
+		period = m.fabricScheduler.min
+	}
+	var fmEventsDone chan struct{}
+	if cfg.FMEvents {
+		fmEventsDone = make(chan struct{})
+		go func() {
+			defer close(fmEventsDone)
+			m.watchFMEvents(ctx, workCtx, controllers)
+		}()
+	}
+
+	var lastScan time.Time
+	wait.Until(func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.fabricScheduler != nil && time.Since(lastScan) < cfg.ScanInterval {
+			fabricCtx, span := tracing.Start(workCtx, "checkFabrics")
+			err := m.checkFabrics(fabricCtx, controllers)
+			tracing.End(span, err)
+			if err != nil {
+				slog.Error("Fabric check failed", "error", err)
+			}
+			return
+		}
+		lastScan = time.Now()
+		slog.Info("Loop Start")
+		// One trace per loop
+		loopCtx, span := tracing.Start(workCtx, "startCheckResourcePoolLoop")
+		err := m.startCheckResourcePoolLoop(loopCtx, controllers)
+		tracing.End(span, err)
+		if err != nil {
+			slog.Error("Loop Failed", "error", err)
+		} else {
+			slog.Info("Loop Successful")
+		}
+	}, period, ctx.Done())
+	if fmEventsDone != nil {
+		<-fmEventsDone
+	}
+	m.shutdown(workCtx, controllers)
+	return nil
+}
+
+// newCDIManager builds the clients of Kubernetes and CDI and runs the Kubernetes controllers.
+func newCDIManager(ctx context.Context, cfg *config.Config) (*CDIManager, error) {
+	kconfig, err := kube_utils.NewClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	coreclient, err := kube_client.NewForConfig(kconfig)
 	if err != nil {
 		slog.Error("Failed to create core client", "error", err)
-		return err
+		return nil, err
 	}
 
 	bmhclient, err := dynamic.NewForConfig(kconfig)
 	if err != nil {
 		slog.Error("Failed to create bmh client", "error", err)
-		return err
+		return nil, err
 	}
 
 	discoveryClient, err := discovery.NewDiscoveryClientForConfig(kconfig)
 	if err != nil {
 		slog.Error("Failed to create discovery client", "error", err)
-		return err
+		return nil, err
 	}
 
 	// Create k8s controllers for Nodes, ConfigMap, Secret and BMH
-	kc, err := kube_utils.CreateKubeControllers(coreclient, bmhclient, discoveryClient, cfg.UseCapiBmh, ctx.Done())
+	watchSecrets := cfg.CredentialsSource == client.CredentialsSourceSecret || len(cfg.CredentialsSource) == 0
+	kc, err := kube_utils.CreateKubeControllers(coreclient, bmhclient, discoveryClient, cfg.UseCapiBmh, watchSecrets, cfg.Namespace, ctx.Done())
 	if err != nil {
 		slog.Error("Failed to create kube controllers")
-		return err
+		return nil, err
 	}
 
 	// Run k8s controllers
 	if err := kc.Run(); err != nil {
 		slog.Error("Failed to run kube controllers")
-		return err
+		return nil, err
 	}
 
 	// Build client to connect CDI components like FM, IM and CM
-	cdiclient, err := client.BuildCDIClient(cfg, kc)
-	if err != nil {
-		return err
+	// In multi-tenant mode, every tenant has its own client instead
+	var cdiclient *client.CDIClient
+	if !cfg.MultiTenant {
+		cdiclient, err = client.BuildCDIClient(cfg, kc)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Get DeviceInfo from ConfigMap
-	cm, err := kc.GetConfigMap(configMapName)
+	cm, err := kc.GetConfigMap(cfg.ConfigMapKey())
 	if err != nil {
 		slog.Error("Cannot get config map for device config", "error", err)
-		return err
+		return nil, err
 	}
 	var devInfos []config.DeviceInfo
 	var labelPrefix string
 	if cm != nil {
 		devInfos, err = config.GetDeviceInfos(cm)
 		if err != nil {
-			return err
+			logValidationError(err)
+			return nil, err
 		}
 		labelPrefix, err = config.GetLabelPrefix(cm)
 		if err != nil {
-			return err
+			logValidationError(err)
+			return nil, err
 		}
 	}
 
@@ -143,8 +257,23 @@ func StartCDIManager(ctx context.Context, cfg *config.Config) error {
 	ndr := initDriverResources(devInfos)
 
 	options := CDIOptions{
-		useCapiBmh: cfg.UseCapiBmh,
-		useCM:      cfg.UseCM,
+		useCapiBmh:    cfg.UseCapiBmh,
+		useCM:         cfg.UseCM,
+		dryRun:        cfg.DryRun,
+		dryRunReport:  cfg.DryRunReport,
+		withdrawPools: cfg.WithdrawPoolsOnShutdown,
+	}
+
+	var tenants []*tenant
+	if cfg.MultiTenant {
+		if cm == nil {
+			return nil, fmt.Errorf("config map for device config is required in multi-tenant mode")
+		}
+		tenants, err = buildTenants(cfg, kc, cm)
+		if err != nil {
+			logValidationError(err)
+			return nil, err
+		}
 	}
 
 	m := &CDIManager{
@@ -155,25 +284,48 @@ func StartCDIManager(ctx context.Context, cfg *config.Config) error {
 		deviceInfos:          devInfos,
 		labelPrefix:          labelPrefix,
 		cdiClient:            cdiclient,
+		tenants:              tenants,
 		kubecontrollers:      kc,
 		cdiOptions:           options,
 	}
+	if cfg.FabricScanIntervalMin > 0 {
+		maxInterval := cfg.FabricScanIntervalMax
+		if maxInterval == 0 {
+			maxInterval = cfg.ScanInterval
+		}
+		m.fabricScheduler = newFabricScheduler(cfg.FabricScanIntervalMin, maxInterval)
+	} else if cfg.FMEvents {
+		// FM events need the machines of each fabric tracked by the scheduler.
+		// Every fabric is still polled in every loop since both bounds are the scan interval,
+		// and the updates on FM events do not move the next polls
+		m.fabricScheduler = newFabricScheduler(cfg.ScanInterval, cfg.ScanInterval)
+	}
+	return m, nil
+}
 
-	controllers, err := m.startResourceSliceController(ctx)
-	if err != nil {
-		return err
+// logValidationError logs every violation of the ConfigMap one by one.
+func logValidationError(err error) {
+	var validationErr *config.ValidationError
+	if !errors.As(err, &validationErr) {
+		return
+	}
+	for _, violation := range validationErr.Violations {
+		slog.Error("invalid config map", "key", validationErr.Key, "path", violation.Path, "entry", violation.Entry, "value", violation.Value, "reason", violation.Reason)
 	}
+}
 
-	wait.Until(func() {
-		slog.Info("Loop Start")
-		err := m.startCheckResourcePoolLoop(ctx, controllers)
-		if err != nil {
-			slog.Error("Loop Failed", "error", err)
-		} else {
-			slog.Info("Loop Successful")
+// revokeTokens revokes the sessions in ID manager of every tenant on shutdown.
+func (m *CDIManager) revokeTokens() {
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeOut)
+	defer cancel()
+	for _, t := range m.getTenants() {
+		if t.cdiClient == nil {
+			continue
 		}
-	}, cfg.ScanInterval, ctx.Done())
-	return nil
+		if err := t.cdiClient.RevokeToken(ctx); err != nil {
+			slog.Warn("Failed to revoke IM token", "tenant", t.name, "error", err)
+		}
+	}
 }
 
 func (m *CDIManager) startResourceSliceController(ctx context.Context) (map[string]*resourceslice.Controller, error) {
@@ -198,9 +350,70 @@ func (m *CDIManager) startResourceSliceController(ctx context.Context) (map[stri
 	return controllers, nil
 }
 
+func buildTenants(cfg *config.Config, kc *kube_utils.KubeControllers, cm *corev1.ConfigMap) ([]*tenant, error) {
+	tenantInfos, err := config.GetTenantInfos(cm)
+	if err != nil {
+		return nil, err
+	}
+	var tenants []*tenant
+	for _, tenantInfo := range tenantInfos {
+		if cfg.UseCM && len(tenantInfo.ClusterID) == 0 {
+			return nil, fmt.Errorf("cluster id must be set for tenant %s when USE_CM is true", tenantInfo.Name)
+		}
+		if !cfg.UseCM && len(tenantInfo.NodeGroups) > 0 {
+			return nil, fmt.Errorf("node groups can not be used for tenant %s when USE_CM is false", tenantInfo.Name)
+		}
+		nodeSelector := labels.Nothing()
+		if len(tenantInfo.NodeSelector) > 0 {
+			nodeSelector = labels.SelectorFromSet(tenantInfo.NodeSelector)
+		}
+		tenantCfg := *cfg
+		tenantCfg.TenantID = tenantInfo.TenantID
+		tenantCfg.ClusterID = tenantInfo.ClusterID
+		tenantCfg.SecretName = tenantInfo.SecretName
+		cdiclient, err := client.BuildCDIClient(&tenantCfg, kc)
+		if err != nil {
+			slog.Error("Failed to build client for tenant", "tenant", tenantInfo.Name)
+			return nil, err
+		}
+		tenants = append(tenants, &tenant{
+			name:         tenantInfo.Name,
+			cdiClient:    cdiclient,
+			nodeSelector: nodeSelector,
+			nodeGroups:   tenantInfo.NodeGroups,
+		})
+		slog.Info("tenant is configured", "tenant", tenantInfo.Name, "tenantID", tenantInfo.TenantID, "nodeSelector", nodeSelector.String(), "nodeGroups", tenantInfo.NodeGroups)
+	}
+	return tenants, nil
+}
+
+func (m *CDIManager) getTenants() []*tenant {
+	if len(m.tenants) > 0 {
+		return m.tenants
+	}
+	return []*tenant{m.defaultTenant()}
+}
+
+func (m *CDIManager) defaultTenant() *tenant {
+	return &tenant{
+		cdiClient:    m.cdiClient,
+		nodeSelector: labels.Everything(),
+	}
+}
+
+func (m *CDIManager) isMultiTenant() bool {
+	return len(m.tenants) > 0
+}
+
 func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers map[string]*resourceslice.Controller) error {
+	if m.cdiOptions.dryRun {
+		m.dryRunReport = &dryRunReport{Time: time.Now()}
+	}
+
 	// Get the map of node name vs machine uuid
+	_, span := tracing.Start(ctx, "getMachineUUIDs")
 	muuids, err := m.getMachineUUIDs()
+	tracing.End(span, err)
 	if err != nil {
 		slog.Error("failed to get machine uuid")
 		return err
@@ -208,24 +421,141 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 	if len(muuids) == 0 {
 		return fmt.Errorf("no machine uuid is found")
 	}
-	// Get list of machine
-	mList, err := m.getMachineList(ctx)
+
+	var machines []*machine
+	var tenantErrs []error
+	var failedTenants []string
+	for _, t := range m.getTenants() {
+		tenantCtx, span := tracing.Start(ctx, "getTenantMachines", attribute.String("tenant", t.name))
+		tenantMachines, err := m.getTenantMachines(tenantCtx, t, muuids)
+		tracing.End(span, err)
+		if err != nil {
+			if !m.isMultiTenant() {
+				return err
+			}
+			// A failure of one tenant must not stop updating devices of other tenants
+			slog.Error("failed to check resource pool of tenant", "tenant", t.name, "error", err)
+			tenantErrs = append(tenantErrs, fmt.Errorf("tenant %s: %w", t.name, err))
+			failedTenants = append(failedTenants, t.name)
+			continue
+		}
+		machines = append(machines, tenantMachines...)
+	}
+	if m.isMultiTenant() {
+		machines = excludeSharedNodes(machines)
+	}
+
+	for _, machine := range machines {
+		slog.Debug("machine information", "nodeName", machine.nodeName, "machineUUID", machine.machineUUID, "fabricID", safeReference(machine.fabricID))
+		for modelName, device := range machine.deviceList {
+			slog.Debug("device information", "nodeName", machine.nodeName, "modelName", modelName, "available", device.availableDeviceCount, "min", safeReference(device.minDeviceCount), "max", safeReference(device.maxDeviceCount))
+		}
+	}
+
+	// Update ResourceSlice using machine infos
+	_, span = tracing.Start(ctx, "manageCDIResourceSlices")
+	m.manageCDIResourceSlices(machines, failedTenants, controllers)
+	span.End()
+
+	// Add labels to Node
+	labelCtx, span := tracing.Start(ctx, "manageCDINodeLabel")
+	err = m.manageCDINodeLabel(labelCtx, machines)
+	if err == nil && m.isMultiTenant() {
+		err = m.removeTenantLabels(labelCtx, muuids, machines, failedTenants)
+	}
+	tracing.End(span, err)
 	if err != nil {
 		return err
 	}
 
+	if m.cdiOptions.dryRun {
+		if err := m.reportDryRun(ctx); err != nil {
+			return err
+		}
+	}
+	return errors.Join(tenantErrs...)
+}
+
+// excludeSharedNodes drops nodes claimed by more than one tenant, so that devices of different tenants are never exposed to the same node.
+func excludeSharedNodes(machines []*machine) []*machine {
+	tenantsOfNode := make(map[string][]string)
+	for _, machine := range machines {
+		tenantsOfNode[machine.nodeName] = append(tenantsOfNode[machine.nodeName], machine.tenant)
+	}
+	var result []*machine
+	for _, machine := range machines {
+		if tenants := tenantsOfNode[machine.nodeName]; len(tenants) > 1 {
+			slog.Warn("the node belongs to multiple tenants, so devices are not exposed to the node", "nodeName", machine.nodeName, "tenants", tenants)
+			continue
+		}
+		result = append(result, machine)
+	}
+	return result
+}
+
+// removeTenantLabels removes the tenant and fabric labels from the nodes which are shared by tenants or claimed by no tenant,
+// so that pools of a tenant are no longer exposed to them. Nodes labeled for a tenant failing in this loop are kept as they are.
+func (m *CDIManager) removeTenantLabels(ctx context.Context, muuids map[string]string, machines []*machine, failedTenants []string) error {
+	claimed := make(map[string]bool)
+	for _, machine := range machines {
+		claimed[machine.nodeName] = true
+	}
+	tenantLabelKey := m.labelPrefix + "/" + "tenant"
+	fabricLabelKey := m.labelPrefix + "/" + "fabric"
+	for nodeName := range muuids {
+		if claimed[nodeName] {
+			continue
+		}
+		node, err := m.kubecontrollers.GetNode(nodeName)
+		if err != nil {
+			slog.Error("failed to get node", "nodeName", nodeName)
+			return err
+		}
+		if node == nil {
+			continue
+		}
+		tenant, labeled := node.Labels[tenantLabelKey]
+		_, fabricLabeled := node.Labels[fabricLabelKey]
+		if (!labeled && !fabricLabeled) || slices.Contains(failedTenants, tenant) {
+			continue
+		}
+		currentLabels := node.Labels
+		// Keep the node in the informer cache as it is
+		node = node.DeepCopy()
+		delete(node.Labels, tenantLabelKey)
+		delete(node.Labels, fabricLabelKey)
+		slog.Info("remove labels of tenant and fabric from the node which no tenant owns", "nodeName", nodeName, "tenant", tenant)
+		if m.cdiOptions.dryRun {
+			m.dryRunReport.addNodeLabels(nodeName, currentLabels, node.Labels)
+			continue
+		}
+		if _, err := m.coreClient.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			slog.Error("failed to update node label", "nodeName", nodeName)
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *CDIManager) getTenantMachines(ctx context.Context, t *tenant, muuids map[string]string) ([]*machine, error) {
+	// Get list of machine
+	mList, err := m.getMachineList(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
 	var ngInfos []*client.CMNodeGroupInfo
 	if m.cdiOptions.useCM {
 		// Get node groups
-		nodeGroups, err := m.getNodeGroups(ctx)
+		nodeGroups, err := m.getNodeGroups(ctx, t)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// Get node group info
 		for _, nodeGroup := range nodeGroups.NodeGroups {
-			ngInfo, err := m.getNodeGroupInfo(ctx, nodeGroup)
+			ngInfo, err := m.getNodeGroupInfo(ctx, t, nodeGroup)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			ngInfos = append(ngInfos, ngInfo)
 		}
@@ -234,6 +564,13 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 	// Create machine which have information of node and devices
 	var machines []*machine
 	for nodeName, muuid := range muuids {
+		belongs, err := m.belongsToTenant(t, nodeName, muuid, ngInfos)
+		if err != nil {
+			return nil, err
+		}
+		if !belongs {
+			continue
+		}
 		// Get fabric id of every machine
 		fabricID := getFabricID(mList, muuid)
 		if fabricID == nil {
@@ -252,6 +589,7 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 			}
 		}
 		machine := &machine{
+			tenant:        t.name,
 			nodeName:      nodeName,
 			machineUUID:   muuid,
 			fabricID:      fabricID,
@@ -261,31 +599,36 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 	}
 
 	if len(machines) == 0 {
-		return fmt.Errorf("no machine is found to process")
+		if m.isMultiTenant() {
+			// The pools of the tenant are withdrawn until any node belongs to it again
+			slog.Info("no machine is found for tenant", "tenant", t.name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no machine is found to process")
 	}
 
 	// Get the number of free devices in a fabric pool
 	// It is executed per a fabric for reducing API calls
 	fabricFound := make(map[int]deviceList)
+	fabricIDs := make(map[int]bool)
 	for _, machine := range machines {
 		if _, exists := fabricFound[*machine.fabricID]; exists {
 			continue
 		}
-		var deviceList deviceList = make(map[string]*device)
-		for _, deviceInfo := range m.deviceInfos {
-			availableNum, err := m.getAvailableNums(ctx, machine.machineUUID, deviceInfo.CDIModelName)
-			if err != nil {
-				return err
-			}
-			deviceList[deviceInfo.CDIModelName] = &device{
-				k8sDeviceName:        deviceInfo.K8sDeviceName,
-				driverName:           deviceInfo.DriverName,
-				draAttributes:        deviceInfo.DRAAttributes,
-				availableDeviceCount: availableNum,
-			}
+		fabricIDs[*machine.fabricID] = true
+		key := fabricKey{tenant: t.name, fabricID: *machine.fabricID}
+		if !m.fabricScheduler.isDue(key) {
+			fabricFound[*machine.fabricID] = m.fabricScheduler.devices(key)
+			continue
 		}
+		deviceList, err := m.getFabricDevices(ctx, t, machine.machineUUID)
+		if err != nil {
+			return nil, err
+		}
+		m.fabricScheduler.observe(key, t, machine.machineUUID, deviceList)
 		fabricFound[*machine.fabricID] = deviceList
 	}
+	m.fabricScheduler.retain(t.name, fabricIDs)
 
 	// Copy device list per a fabric into all machines
 	for fabricID, deviceList := range fabricFound {
@@ -314,9 +657,9 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 			}
 			var deviceMinMax deviceMinMax = make(map[string]limit)
 			for model := range machine.deviceList {
-				min, max, err := m.getMinMaxNums(ctx, machine.machineUUID, model)
+				min, max, err := m.getMinMaxNums(ctx, t, machine.machineUUID, model)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				deviceMinMax[model] = limit{min: min, max: max}
 			}
@@ -340,23 +683,47 @@ func (m *CDIManager) startCheckResourcePoolLoop(ctx context.Context, controllers
 			}
 		}
 	}
+	return machines, nil
+}
 
-	for _, machine := range machines {
-		slog.Debug("machine information", "nodeName", machine.nodeName, "machineUUID", machine.machineUUID, "fabricID", safeReference(machine.fabricID))
-		for modelName, device := range machine.deviceList {
-			slog.Debug("device information", "nodeName", machine.nodeName, "modelName", modelName, "available", device.availableDeviceCount, "min", safeReference(device.minDeviceCount), "max", safeReference(device.maxDeviceCount))
+// getFabricDevices gets the number of free devices of every model in the fabric of the machine.
+func (m *CDIManager) getFabricDevices(ctx context.Context, t *tenant, muuid string) (deviceList, error) {
+	var deviceList deviceList = make(map[string]*device)
+	for _, deviceInfo := range m.deviceInfos {
+		availableNum, err := m.getAvailableNums(ctx, t, muuid, deviceInfo.CDIModelName)
+		if err != nil {
+			return nil, err
+		}
+		deviceList[deviceInfo.CDIModelName] = &device{
+			k8sDeviceName:        deviceInfo.K8sDeviceName,
+			driverName:           deviceInfo.DriverName,
+			draAttributes:        deviceInfo.DRAAttributes,
+			availableDeviceCount: availableNum,
 		}
 	}
+	return deviceList, nil
+}
 
-	// Update ResourceSlice using machine infos
-	m.manageCDIResourceSlices(machines, controllers)
-
-	// Add labels to Node
-	err = m.manageCDINodeLabel(ctx, machines)
+func (m *CDIManager) belongsToTenant(t *tenant, nodeName string, muuid string, ngInfos []*client.CMNodeGroupInfo) (bool, error) {
+	for _, ngInfo := range ngInfos {
+		if slices.Contains(t.nodeGroups, ngInfo.UUID) || slices.Contains(t.nodeGroups, ngInfo.Name) {
+			if slices.Contains(ngInfo.MachineIDs, muuid) {
+				return true, nil
+			}
+		}
+	}
+	if t.nodeSelector.Empty() {
+		return true, nil
+	}
+	node, err := m.kubecontrollers.GetNode(nodeName)
 	if err != nil {
-		return err
+		slog.Error("failed to get node", "nodeName", nodeName)
+		return false, err
 	}
-	return nil
+	if node == nil {
+		return false, nil
+	}
+	return t.nodeSelector.Matches(labels.Set(node.Labels)), nil
 }
 
 func (m *CDIManager) getMachineUUIDs() (map[string]string, error) {
@@ -398,14 +765,18 @@ func (m *CDIManager) getMachineUUIDs() (map[string]string, error) {
 	return uuids, nil
 }
 
-func (m *CDIManager) getMachineList(ctx context.Context) (*client.FMMachineList, error) {
+func (m *CDIManager) getMachineList(ctx context.Context, t *tenant) (*client.FMMachineList, error) {
 	ctx = context.WithValue(ctx, client.RequestIDKey{}, config.RandomString(6))
 	slog.Debug("trying to get machine list from FabricManager", "requestID", client.GetRequestIdFromContext(ctx))
+	ctx, span := tracing.Start(ctx, "getMachineList", tracing.RequestIDKey.String(client.GetRequestIdFromContext(ctx)))
+	defer span.End()
 
 	// Publish API to get a machine list from FabricManager
-	mList, err := m.cdiClient.GetFMMachineList(ctx)
+	mList, err := t.cdiClient.GetFMMachineList(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("FM machine list API failed, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("FM machine list API failed, requestID=%s: %w", client.GetRequestIdFromContext(ctx), err)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 	slog.Debug("FM machine list API completed successfully", "requestID", client.GetRequestIdFromContext(ctx))
 	for _, machine := range mList.Data.Machines {
@@ -414,30 +785,40 @@ func (m *CDIManager) getMachineList(ctx context.Context) (*client.FMMachineList,
 	return mList, nil
 }
 
-func (m *CDIManager) getAvailableNums(ctx context.Context, muuid string, modelName string) (int, error) {
+func (m *CDIManager) getAvailableNums(ctx context.Context, t *tenant, muuid string, modelName string) (int, error) {
 	ctx = context.WithValue(ctx, client.RequestIDKey{}, config.RandomString(6))
 	slog.Debug("trying to get available reserved resources from FabricManager", "machineUUID", muuid, "modelName", modelName, "requestID", client.GetRequestIdFromContext(ctx))
+	ctx, span := tracing.Start(ctx, "getAvailableNums", tracing.RequestIDKey.String(client.GetRequestIdFromContext(ctx)), attribute.String("machineUUID", muuid), attribute.String("modelName", modelName))
+	defer span.End()
 
 	// Publish API to get available reserved resources from FabricManager
-	availableResources, err := m.cdiClient.GetFMAvailableReservedResources(ctx, muuid, modelName)
+	availableResources, err := t.cdiClient.GetFMAvailableReservedResources(ctx, muuid, modelName)
 	if err != nil {
-		return 0, fmt.Errorf("FM available reserved resources API failed, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("FM available reserved resources API failed, requestID=%s: %w", client.GetRequestIdFromContext(ctx), err)
+		tracing.RecordError(span, err)
+		return 0, err
 	}
 	if availableResources.ReservedResourceNum > 128 {
-		return 0, fmt.Errorf("FM available reserved resources exceeds 128, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("FM available reserved resources exceeds 128, requestID=%s", client.GetRequestIdFromContext(ctx))
+		tracing.RecordError(span, err)
+		return 0, err
 	}
 	slog.Debug("FM available reserved resources API completed successfully", "requestID", client.GetRequestIdFromContext(ctx))
 	return availableResources.ReservedResourceNum, nil
 }
 
-func (m *CDIManager) getNodeGroups(ctx context.Context) (*client.CMNodeGroups, error) {
+func (m *CDIManager) getNodeGroups(ctx context.Context, t *tenant) (*client.CMNodeGroups, error) {
 	ctx = context.WithValue(ctx, client.RequestIDKey{}, config.RandomString(6))
 	slog.Debug("trying to get node groups from ClusterManager", "requestID", client.GetRequestIdFromContext(ctx))
+	ctx, span := tracing.Start(ctx, "getNodeGroups", tracing.RequestIDKey.String(client.GetRequestIdFromContext(ctx)))
+	defer span.End()
 
 	// Publish API to get node groups from ClusterManager
-	nodeGroups, err := m.cdiClient.GetCMNodeGroups(ctx)
+	nodeGroups, err := t.cdiClient.GetCMNodeGroups(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("CM node groups API failed, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("CM node groups API failed, requestID=%s: %w", client.GetRequestIdFromContext(ctx), err)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 	slog.Debug("CM node groups API completed successfully", "requestID", client.GetRequestIdFromContext(ctx))
 	for _, nodeGroup := range nodeGroups.NodeGroups {
@@ -446,14 +827,18 @@ func (m *CDIManager) getNodeGroups(ctx context.Context) (*client.CMNodeGroups, e
 	return nodeGroups, nil
 }
 
-func (m *CDIManager) getNodeGroupInfo(ctx context.Context, nodeGroup client.CMNodeGroup) (*client.CMNodeGroupInfo, error) {
+func (m *CDIManager) getNodeGroupInfo(ctx context.Context, t *tenant, nodeGroup client.CMNodeGroup) (*client.CMNodeGroupInfo, error) {
 	ctx = context.WithValue(ctx, client.RequestIDKey{}, config.RandomString(6))
 	slog.Debug("trying to get node group info from ClusterManager", "nodeGroupName", nodeGroup.Name, "requestID", client.GetRequestIdFromContext(ctx))
+	ctx, span := tracing.Start(ctx, "getNodeGroupInfo", tracing.RequestIDKey.String(client.GetRequestIdFromContext(ctx)), attribute.String("nodeGroupName", nodeGroup.Name))
+	defer span.End()
 
 	// Publish API to get a node group info from ClusterManager
-	nodeGroupInfo, err := m.cdiClient.GetCMNodeGroupInfo(ctx, nodeGroup)
+	nodeGroupInfo, err := t.cdiClient.GetCMNodeGroupInfo(ctx, nodeGroup)
 	if err != nil {
-		return nil, fmt.Errorf("CM node group info API failed, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("CM node group info API failed, requestID=%s: %w", client.GetRequestIdFromContext(ctx), err)
+		tracing.RecordError(span, err)
+		return nil, err
 	}
 	slog.Debug("CM node group info API completed successfully", "requestID", client.GetRequestIdFromContext(ctx))
 	for _, machineID := range nodeGroupInfo.MachineIDs {
@@ -462,14 +847,18 @@ func (m *CDIManager) getNodeGroupInfo(ctx context.Context, nodeGroup client.CMNo
 	return nodeGroupInfo, nil
 }
 
-func (m *CDIManager) getMinMaxNums(ctx context.Context, muuid string, modelName string) (min *int, max *int, error error) {
+func (m *CDIManager) getMinMaxNums(ctx context.Context, t *tenant, muuid string, modelName string) (min *int, max *int, error error) {
 	ctx = context.WithValue(ctx, client.RequestIDKey{}, config.RandomString(6))
 	slog.Debug("trying to get node details from ClusterManager", "machineUUID", muuid, "modelName", modelName, "requestID", client.GetRequestIdFromContext(ctx))
+	ctx, span := tracing.Start(ctx, "getMinMaxNums", tracing.RequestIDKey.String(client.GetRequestIdFromContext(ctx)), attribute.String("machineUUID", muuid), attribute.String("modelName", modelName))
+	defer span.End()
 
 	// Publish API to get node details from ClusterManager
-	nodeDetails, err := m.cdiClient.GetCMNodeDetails(ctx, muuid)
+	nodeDetails, err := t.cdiClient.GetCMNodeDetails(ctx, muuid)
 	if err != nil {
-		return nil, nil, fmt.Errorf("CM node details API failed, requestID=%s", client.GetRequestIdFromContext(ctx))
+		err = fmt.Errorf("CM node details API failed, requestID=%s: %w", client.GetRequestIdFromContext(ctx), err)
+		tracing.RecordError(span, err)
+		return nil, nil, err
 	}
 	slog.Debug("CM node details API completed successfully", "requestID", client.GetRequestIdFromContext(ctx))
 	for _, resspec := range nodeDetails.Data.Cluster.Machine.ResSpecs {
@@ -487,52 +876,113 @@ func (m *CDIManager) getMinMaxNums(ctx context.Context, muuid string, modelName
 	return min, max, nil
 }
 
-func (m *CDIManager) manageCDIResourceSlices(machines []*machine, controlles map[string]*resourceslice.Controller) {
-	needUpdate := make(map[string]bool)
-	fabricFound := make(map[int]bool)
+func (m *CDIManager) manageCDIResourceSlices(machines []*machine, failedTenants []string, controlles map[string]*resourceslice.Controller) {
+	type tenantFabric struct {
+		tenant   string
+		fabricID int
+	}
+	needUpdate := m.pruneTenantPools(machines, failedTenants)
+	fabricFound := make(map[tenantFabric]bool)
 	for _, machine := range machines {
 		if machine.fabricID != nil {
-			if !fabricFound[*machine.fabricID] {
+			key := tenantFabric{tenant: machine.tenant, fabricID: *machine.fabricID}
+			if !fabricFound[key] {
 				for _, device := range machine.deviceList {
 					if _, exist := m.namedDriverResources[device.driverName]; exist {
-						poolName := device.k8sDeviceName + "-fabric" + strconv.Itoa(*machine.fabricID)
-						updated := m.updatePool(poolName, device, *machine.fabricID)
+						poolName := getPoolName(device, machine.tenant, *machine.fabricID)
+						updated := m.updatePool(poolName, device, machine.tenant, *machine.fabricID)
 						if updated {
 							slog.Info("pool update", "poolName", poolName, "generation", m.namedDriverResources[device.driverName].Pools[poolName].Generation, "driver", device.driverName)
 							needUpdate[device.driverName] = true
 						}
 					}
 				}
-				fabricFound[*machine.fabricID] = true
+				fabricFound[key] = true
 			}
 		}
 	}
+	m.updateControllers(controlles, needUpdate)
+}
+
+// updateControllers publishes the pools of the drivers which are updated.
+func (m *CDIManager) updateControllers(controllers map[string]*resourceslice.Controller, needUpdate map[string]bool) {
 	for driverName, driverResources := range m.namedDriverResources {
-		if needUpdate[driverName] {
-			c := controlles[driverName]
+		if needUpdate[driverName] && !m.cdiOptions.dryRun {
+			c := controllers[driverName]
 			c.Update(driverResources)
 		}
 	}
 }
 
-func (m *CDIManager) updatePool(poolName string, device *device, fabricID int) (updated bool) {
+// pruneTenantPools deletes the pools of tenants which are not found in machines, e.g. the tenant has no node any more or is removed.
+// The pools of failed tenants are kept as they are, and pools out of multi-tenant mode are never pruned.
+func (m *CDIManager) pruneTenantPools(machines []*machine, failedTenants []string) map[string]bool {
+	found := make(map[string]bool)
+	for _, machine := range machines {
+		if machine.fabricID == nil {
+			continue
+		}
+		for _, device := range machine.deviceList {
+			found[getPoolName(device, machine.tenant, *machine.fabricID)] = true
+		}
+	}
+	needUpdate := make(map[string]bool)
+	for driverName, driverResources := range m.namedDriverResources {
+		for poolName, pool := range driverResources.Pools {
+			tenant := m.getPoolTenant(pool)
+			if len(tenant) == 0 || found[poolName] || slices.Contains(failedTenants, tenant) {
+				continue
+			}
+			delete(driverResources.Pools, poolName)
+			slog.Info("pool delete", "poolName", poolName, "tenant", tenant, "driver", driverName)
+			needUpdate[driverName] = true
+		}
+	}
+	return needUpdate
+}
+
+// getPoolTenant returns the tenant in the node selector of the pool, which is empty out of multi-tenant mode.
+func (m *CDIManager) getPoolTenant(pool resourceslice.Pool) string {
+	if pool.NodeSelector == nil {
+		return ""
+	}
+	for _, term := range pool.NodeSelector.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == m.labelPrefix+"/"+"tenant" && len(expression.Values) > 0 {
+				return expression.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// getPoolName returns the DRA pool name for a device in a fabric.
+// The tenant name is inserted in multi-tenant mode because fabric ids are only unique within a tenant.
+func getPoolName(device *device, tenant string, fabricID int) string {
+	if len(tenant) > 0 {
+		return device.k8sDeviceName + "-" + tenant + "-fabric" + strconv.Itoa(fabricID)
+	}
+	return device.k8sDeviceName + "-fabric" + strconv.Itoa(fabricID)
+}
+
+func (m *CDIManager) updatePool(poolName string, device *device, tenant string, fabricID int) (updated bool) {
 	var generation int64 = 1
 	pool := m.namedDriverResources[device.driverName].Pools[poolName]
 	if len(pool.Slices) == 0 {
-		m.namedDriverResources[device.driverName].Pools[poolName] = m.generatePool(device, fabricID, generation)
+		m.namedDriverResources[device.driverName].Pools[poolName] = m.generatePool(device, tenant, fabricID, generation)
 		return true
 	} else {
 		if len(pool.Slices[0].Devices) != device.availableDeviceCount {
 			generation = pool.Generation
 			generation++
-			m.namedDriverResources[device.driverName].Pools[poolName] = m.generatePool(device, fabricID, generation)
+			m.namedDriverResources[device.driverName].Pools[poolName] = m.generatePool(device, tenant, fabricID, generation)
 			return true
 		}
 	}
 	return false
 }
 
-func (m *CDIManager) generatePool(device *device, fabricID int, generation int64) resourceslice.Pool {
+func (m *CDIManager) generatePool(device *device, tenant string, fabricID int, generation int64) resourceslice.Pool {
 	var devices []resourceapi.Device
 	for i := 0; i < device.availableDeviceCount; i++ {
 		d := resourceapi.Device{
@@ -547,26 +997,37 @@ func (m *CDIManager) generatePool(device *device, fabricID int, generation int64
 		}
 		devices = append(devices, d)
 	}
+	matchExpressions := []corev1.NodeSelectorRequirement{
+		{
+			Key:      m.labelPrefix + "/" + device.k8sDeviceName,
+			Operator: corev1.NodeSelectorOpIn,
+			Values: []string{
+				"true",
+			},
+		},
+		{
+			Key:      m.labelPrefix + "/" + "fabric",
+			Operator: corev1.NodeSelectorOpIn,
+			Values: []string{
+				strconv.Itoa(fabricID),
+			},
+		},
+	}
+	if len(tenant) > 0 {
+		// Keep devices of a tenant apart from nodes of other tenants
+		matchExpressions = append(matchExpressions, corev1.NodeSelectorRequirement{
+			Key:      m.labelPrefix + "/" + "tenant",
+			Operator: corev1.NodeSelectorOpIn,
+			Values: []string{
+				tenant,
+			},
+		})
+	}
 	pool := resourceslice.Pool{
 		NodeSelector: &corev1.NodeSelector{
 			NodeSelectorTerms: []corev1.NodeSelectorTerm{
 				{
-					MatchExpressions: []corev1.NodeSelectorRequirement{
-						{
-							Key:      m.labelPrefix + "/" + device.k8sDeviceName,
-							Operator: corev1.NodeSelectorOpIn,
-							Values: []string{
-								"true",
-							},
-						},
-						{
-							Key:      m.labelPrefix + "/" + "fabric",
-							Operator: corev1.NodeSelectorOpIn,
-							Values: []string{
-								strconv.Itoa(fabricID),
-							},
-						},
-					},
+					MatchExpressions: matchExpressions,
 				},
 			},
 		},
@@ -589,6 +1050,21 @@ func (m *CDIManager) manageCDINodeLabel(ctx context.Context, machines []*machine
 		}
 		fabricLabelKey := m.labelPrefix + "/" + "fabric"
 		if node != nil {
+			// Keep the node in the informer cache as it is
+			node = node.DeepCopy()
+			var currentLabels map[string]string
+			if m.cdiOptions.dryRun {
+				currentLabels = maps.Clone(node.Labels)
+			}
+			// Label for tenant
+			if len(machine.tenant) > 0 {
+				tenantLabelKey := m.labelPrefix + "/" + "tenant"
+				if node.Labels[tenantLabelKey] != machine.tenant {
+					node.Labels[tenantLabelKey] = machine.tenant
+					slog.Info("set labels for tenant", "nodeName", machine.nodeName, "label", tenantLabelKey+"="+machine.tenant)
+				}
+			}
+
 			// Label for fabric
 			if machine.fabricID != nil {
 				fabricID := strconv.Itoa(*machine.fabricID)
@@ -623,6 +1099,10 @@ func (m *CDIManager) manageCDINodeLabel(ctx context.Context, machines []*machine
 					}
 				}
 			}
+			if m.cdiOptions.dryRun {
+				m.dryRunReport.addNodeLabels(machine.nodeName, currentLabels, node.Labels)
+				continue
+			}
 			_, err = m.coreClient.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
 			if err != nil {
 				slog.Error("failed to update node label", "nodeName", machine.nodeName)
@@ -20,9 +20,11 @@ import (
 	"cdi_dra/pkg/client"
 	"cdi_dra/pkg/config"
 	ku "cdi_dra/pkg/kube_utils"
+	"cdi_dra/pkg/tracing"
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http/httptest"
 	"os"
 	"reflect"
@@ -31,10 +33,14 @@ import (
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	v1 "k8s.io/api/core/v1"
 	resourceapi "k8s.io/api/resource/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/dynamic-resource-allocation/resourceslice"
 	"k8s.io/utils/ptr"
@@ -704,6 +710,410 @@ func TestCheckResourcePoolLoop(t *testing.T) {
 	}
 }
 
+func TestCheckResourcePoolLoopMultiTenant(t *testing.T) {
+	testCases := []struct {
+		name                string
+		nodeTenants         map[string]string
+		expectedPoolNames   []string
+		expectedNodeTenants map[string]string
+		expectedErr         bool
+		expectedErrMsg      string
+	}{
+		{
+			name: "When devices of every tenant are published separately",
+			nodeTenants: map[string]string{
+				"test-node-0": "tenant-a",
+				"test-node-1": "tenant-b",
+				"test-node-2": "tenant-b",
+			},
+			expectedPoolNames: []string{
+				"test-device-1-tenant-a-fabric1",
+				"test-device-1-tenant-b-fabric2",
+				"test-device-1-tenant-b-fabric3",
+			},
+			expectedNodeTenants: map[string]string{
+				"test-node-0": "tenant-a",
+				"test-node-1": "tenant-b",
+				"test-node-2": "tenant-b",
+				"test-node-3": "",
+			},
+			expectedErr: false,
+		},
+		{
+			name: "When a tenant has no node, other tenants are processed",
+			nodeTenants: map[string]string{
+				"test-node-1": "tenant-b",
+			},
+			expectedPoolNames: []string{
+				"test-device-1-tenant-b-fabric2",
+			},
+			expectedNodeTenants: map[string]string{
+				"test-node-0": "",
+				"test-node-1": "tenant-b",
+			},
+			expectedErr: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				DRAenabled:         true,
+				CaseDriverResource: CaseDriverResourceEmpty,
+				TenantID:           "00000000-0000-0001-0000-000000000000",
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+
+			tenantBClient := *m.cdiClient
+			tenantBClient.TenantId = "00000000-0000-0002-0000-000000000000"
+			m.tenants = []*tenant{
+				{
+					name:         "tenant-a",
+					cdiClient:    m.cdiClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant": "a"}),
+				},
+				{
+					name:         "tenant-b",
+					cdiClient:    &tenantBClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant": "b"}),
+				},
+			}
+			for nodeName, tenantName := range tc.nodeTenants {
+				node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get Node: %v", err)
+				}
+				node.Labels["example.com/tenant"] = strings.TrimPrefix(tenantName, "tenant-")
+				_, err = m.coreClient.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+				if err != nil {
+					t.Fatalf("failed to update Node: %v", err)
+				}
+			}
+			time.Sleep(1 * time.Second)
+
+			rscontrolles := createTestResourceSliceControllers(t, m.coreClient)
+
+			err := m.startCheckResourcePoolLoop(context.Background(), rscontrolles)
+
+			if tc.expectedErr {
+				if err == nil {
+					t.Error("expected error, but got none")
+				}
+				if err != nil && !strings.Contains(err.Error(), tc.expectedErrMsg) {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			time.Sleep(3 * time.Second)
+			resourceslices, err := m.coreClient.ResourceV1().ResourceSlices().List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Errorf("unexpected error in kube client List")
+			}
+			var poolNames []string
+			for _, resourceslice := range resourceslices.Items {
+				poolNames = append(poolNames, resourceslice.Spec.Pool.Name)
+			}
+			for _, expectedPoolName := range tc.expectedPoolNames {
+				if !slices.Contains(poolNames, expectedPoolName) {
+					t.Errorf("expected pool is not found, expected %s in %v", expectedPoolName, poolNames)
+				}
+			}
+			for nodeName, expectedTenant := range tc.expectedNodeTenants {
+				node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("not found node, node name: %s", nodeName)
+				}
+				if node.Labels["cohdi.com/tenant"] != expectedTenant {
+					t.Errorf("unexpected label of tenant in %s, expected %s but got %s", nodeName, expectedTenant, node.Labels["cohdi.com/tenant"])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckResourcePoolLoopMultiTenantPrunePools(t *testing.T) {
+	testCases := []struct {
+		name             string
+		update           func(t *testing.T, m *CDIManager)
+		expectedPools    []string
+		notExpectedPools []string
+		expectedErr      bool
+	}{
+		{
+			name: "When a tenant has no node any more",
+			update: func(t *testing.T, m *CDIManager) {
+				setTestNodeLabel(t, m, "test-node-0", "example.com/tenant", "")
+			},
+			expectedPools:    []string{"test-device-1-tenant-b-fabric2"},
+			notExpectedPools: []string{"test-device-1-tenant-a-fabric1"},
+		},
+		{
+			name: "When a tenant is removed",
+			update: func(t *testing.T, m *CDIManager) {
+				m.tenants = m.tenants[1:]
+			},
+			expectedPools:    []string{"test-device-1-tenant-b-fabric2"},
+			notExpectedPools: []string{"test-device-1-tenant-a-fabric1"},
+		},
+		{
+			name: "When a tenant fails",
+			update: func(t *testing.T, m *CDIManager) {
+				failedClient := *m.tenants[0].cdiClient
+				failedClient.TenantId = "00000000-0000-0404-0000-000000000000"
+				m.tenants[0].cdiClient = &failedClient
+			},
+			expectedPools: []string{"test-device-1-tenant-a-fabric1", "test-device-1-tenant-b-fabric2"},
+			expectedErr:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				DRAenabled:         true,
+				CaseDriverResource: CaseDriverResourceEmpty,
+				TenantID:           "00000000-0000-0001-0000-000000000000",
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+
+			tenantBClient := *m.cdiClient
+			tenantBClient.TenantId = "00000000-0000-0002-0000-000000000000"
+			m.tenants = []*tenant{
+				{
+					name:         "tenant-a",
+					cdiClient:    m.cdiClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant": "a"}),
+				},
+				{
+					name:         "tenant-b",
+					cdiClient:    &tenantBClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant": "b"}),
+				},
+			}
+			setTestNodeLabel(t, m, "test-node-0", "example.com/tenant", "a")
+			setTestNodeLabel(t, m, "test-node-1", "example.com/tenant", "b")
+			time.Sleep(1 * time.Second)
+
+			rscontrolles := createTestResourceSliceControllers(t, m.coreClient)
+			if err := m.startCheckResourcePoolLoop(context.Background(), rscontrolles); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pools := m.namedDriverResources["test-driver-1"].Pools
+			if _, exist := pools["test-device-1-tenant-a-fabric1"]; !exist {
+				t.Fatal("pool of tenant-a is not published")
+			}
+
+			tc.update(t, m)
+			time.Sleep(1 * time.Second)
+			err := m.startCheckResourcePoolLoop(context.Background(), rscontrolles)
+			if tc.expectedErr && err == nil {
+				t.Error("expected error, but got none")
+			} else if !tc.expectedErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for _, poolName := range tc.expectedPools {
+				if _, exist := pools[poolName]; !exist {
+					t.Errorf("expected pool %s is not found", poolName)
+				}
+			}
+			for _, poolName := range tc.notExpectedPools {
+				if _, exist := pools[poolName]; exist {
+					t.Errorf("pool %s is not pruned", poolName)
+				}
+			}
+		})
+	}
+}
+
+// setTestNodeLabel sets the label of the node, or deletes it if value is empty.
+func setTestNodeLabel(t *testing.T, m *CDIManager, nodeName string, key string, value string) {
+	node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get Node: %v", err)
+	}
+	if len(value) > 0 {
+		node.Labels[key] = value
+	} else {
+		delete(node.Labels, key)
+	}
+	if _, err := m.coreClient.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to update Node: %v", err)
+	}
+}
+
+func TestCheckResourcePoolLoopMultiTenantRemoveLabels(t *testing.T) {
+	testCases := []struct {
+		name           string
+		movedNode      string
+		movedLabels    map[string]string
+		expectedTenant string
+	}{
+		{
+			name:        "When a node becomes shared by tenants",
+			movedNode:   "test-node-1",
+			movedLabels: map[string]string{"example.com/tenant-a": "true", "example.com/tenant-b": "true"},
+		},
+		{
+			name:        "When a node becomes claimed by no tenant",
+			movedNode:   "test-node-1",
+			movedLabels: map[string]string{},
+		},
+		{
+			name:           "When a node moves to another tenant",
+			movedNode:      "test-node-1",
+			movedLabels:    map[string]string{"example.com/tenant-a": "true"},
+			expectedTenant: "tenant-a",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				DRAenabled:         true,
+				CaseDriverResource: CaseDriverResourceEmpty,
+				TenantID:           "00000000-0000-0002-0000-000000000000",
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+
+			// Both tenants see every machine in FM, so that a node can be claimed by both of them
+			m.tenants = []*tenant{
+				{
+					name:         "tenant-a",
+					cdiClient:    m.cdiClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant-a": "true"}),
+				},
+				{
+					name:         "tenant-b",
+					cdiClient:    m.cdiClient,
+					nodeSelector: labels.SelectorFromSet(labels.Set{"example.com/tenant-b": "true"}),
+				},
+			}
+			setTenantLabels := func(nodeName string, tenantLabels map[string]string) {
+				node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get Node: %v", err)
+				}
+				delete(node.Labels, "example.com/tenant-a")
+				delete(node.Labels, "example.com/tenant-b")
+				maps.Copy(node.Labels, tenantLabels)
+				if _, err := m.coreClient.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{}); err != nil {
+					t.Fatalf("failed to update Node: %v", err)
+				}
+			}
+			setTenantLabels("test-node-0", map[string]string{"example.com/tenant-a": "true"})
+			setTenantLabels("test-node-1", map[string]string{"example.com/tenant-b": "true"})
+			setTenantLabels("test-node-2", map[string]string{"example.com/tenant-b": "true"})
+			time.Sleep(1 * time.Second)
+
+			rscontrolles := createTestResourceSliceControllers(t, m.coreClient)
+			if err := m.startCheckResourcePoolLoop(context.Background(), rscontrolles); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node, err := m.coreClient.CoreV1().Nodes().Get(context.Background(), tc.movedNode, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("not found node, node name: %s", tc.movedNode)
+			}
+			if node.Labels["cohdi.com/tenant"] != "tenant-b" || node.Labels["cohdi.com/fabric"] == "" {
+				t.Fatalf("node is not labeled for tenant-b: %v", node.Labels)
+			}
+
+			setTenantLabels(tc.movedNode, tc.movedLabels)
+			time.Sleep(1 * time.Second)
+			if err := m.startCheckResourcePoolLoop(context.Background(), rscontrolles); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			node, err = m.coreClient.CoreV1().Nodes().Get(context.Background(), tc.movedNode, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("not found node, node name: %s", tc.movedNode)
+			}
+			if node.Labels["cohdi.com/tenant"] != tc.expectedTenant {
+				t.Errorf("unexpected label of tenant, expected %q but got %q", tc.expectedTenant, node.Labels["cohdi.com/tenant"])
+			}
+			_, fabricLabeled := node.Labels["cohdi.com/fabric"]
+			if fabricLabeled != (len(tc.expectedTenant) > 0) {
+				t.Errorf("unexpected label of fabric: %v", node.Labels)
+			}
+		})
+	}
+}
+
+func TestCheckResourcePoolLoopTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(prevProvider)
+
+	testSpec := config.TestSpec{
+		UseCapiBmh: false,
+		DRAenabled: true,
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+	rscontrolles := createTestResourceSliceControllers(t, m.coreClient)
+
+	ctx, span := tracing.Start(context.Background(), "startCheckResourcePoolLoop")
+	err := m.startCheckResourcePoolLoop(ctx, rscontrolles)
+	tracing.End(span, err)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = append(spans[s.Name()], s)
+	}
+	for _, name := range []string{"startCheckResourcePoolLoop", "getMachineUUIDs", "getTenantMachines", "getMachineList", "getAvailableNums", "manageCDIResourceSlices", "manageCDINodeLabel", "HTTP GET"} {
+		if len(spans[name]) == 0 {
+			t.Errorf("span %s is not recorded", name)
+		}
+	}
+	traceID := span.SpanContext().TraceID()
+	for _, name := range []string{"getMachineUUIDs", "getTenantMachines", "getMachineList", "getAvailableNums", "manageCDIResourceSlices", "manageCDINodeLabel", "HTTP GET"} {
+		for _, s := range spans[name] {
+			if s.SpanContext().TraceID() != traceID {
+				t.Errorf("span %s is not in the trace of the loop", name)
+			}
+		}
+	}
+	for _, s := range append(spans["getMachineList"], spans["getAvailableNums"]...) {
+		var requestID string
+		for _, attr := range s.Attributes() {
+			if attr.Key == tracing.RequestIDKey {
+				requestID = attr.Value.AsString()
+			}
+		}
+		if len(requestID) != 6 {
+			t.Errorf("unexpected request ID of span %s: %q", s.Name(), requestID)
+		}
+		if len(spans["getTenantMachines"]) > 0 && s.Parent().SpanID() != spans["getTenantMachines"][0].SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of getTenantMachines", s.Name())
+		}
+	}
+}
+
+func TestExcludeSharedNodes(t *testing.T) {
+	machines := []*machine{
+		{tenant: "tenant-a", nodeName: "test-node-0"},
+		{tenant: "tenant-a", nodeName: "test-node-1"},
+		{tenant: "tenant-b", nodeName: "test-node-1"},
+		{tenant: "tenant-b", nodeName: "test-node-2"},
+	}
+	result := excludeSharedNodes(machines)
+	var nodeNames []string
+	for _, machine := range result {
+		nodeNames = append(nodeNames, machine.nodeName)
+	}
+	if !reflect.DeepEqual(nodeNames, []string{"test-node-0", "test-node-2"}) {
+		t.Errorf("unexpected nodes, expected [test-node-0 test-node-2] but got %v", nodeNames)
+	}
+}
+
 func TestCDIManagerGetMachineUUID(t *testing.T) {
 	testCases := []struct {
 		name                     string
@@ -789,6 +1199,8 @@ func TestCDIManagerGetMachineList(t *testing.T) {
 	testCases := []struct {
 		name              string
 		tenantId          string
+		namespace         string
+		secretName        string
 		expectedNodeCount int
 		expectedErr       bool
 		expectedErrMsg    string
@@ -798,6 +1210,13 @@ func TestCDIManagerGetMachineList(t *testing.T) {
 			expectedNodeCount: 9,
 			expectedErr:       false,
 		},
+		{
+			name:              "When credentials are in Secret of non-default namespace and name",
+			namespace:         "cdi-dra-test",
+			secretName:        "cdi-dra-credentials",
+			expectedNodeCount: 9,
+			expectedErr:       false,
+		},
 		{
 			name:           "When machine list API is failed",
 			tenantId:       "00000000-0000-0404-0000-000000000000",
@@ -811,12 +1230,14 @@ func TestCDIManagerGetMachineList(t *testing.T) {
 				UseCapiBmh: false,
 				DRAenabled: true,
 				TenantID:   tc.tenantId,
+				Namespace:  tc.namespace,
+				SecretName: tc.secretName,
 			}
 			m, server, stopKubeController := createTestManager(t, testSpec)
 			defer stopKubeController()
 			defer server.Close()
 
-			mList, err := m.getMachineList(context.Background())
+			mList, err := m.getMachineList(context.Background(), m.defaultTenant())
 			if tc.expectedErr {
 				if err == nil {
 					t.Errorf("expected error, but got none")
@@ -876,7 +1297,7 @@ func TestCDIManagerGetAvailableNums(t *testing.T) {
 			defer stopKubeController()
 			defer server.Close()
 
-			availableResources, err := m.getAvailableNums(context.Background(), tc.machineUUID, tc.modelName)
+			availableResources, err := m.getAvailableNums(context.Background(), m.defaultTenant(), tc.machineUUID, tc.modelName)
 			if tc.expectedErr {
 				if err == nil {
 					t.Error("expected error, but got none")
@@ -926,7 +1347,7 @@ func TestCDIManagerGetNodeGroups(t *testing.T) {
 			defer stopKubeController()
 			defer server.Close()
 
-			nodeGroups, err := m.getNodeGroups(context.Background())
+			nodeGroups, err := m.getNodeGroups(context.Background(), m.defaultTenant())
 			if tc.expectedErr {
 				if err == nil {
 					t.Error("expected error, but got none")
@@ -984,7 +1405,7 @@ func TestCDIManagerGetNodeGroupInfo(t *testing.T) {
 			defer stopKubeController()
 			defer server.Close()
 
-			nodeGroupInfo, err := m.getNodeGroupInfo(context.Background(), tc.nodeGroup)
+			nodeGroupInfo, err := m.getNodeGroupInfo(context.Background(), m.defaultTenant(), tc.nodeGroup)
 			if tc.expectedErr {
 				if err == nil {
 					t.Error("expected error, but got none")
@@ -1059,7 +1480,7 @@ func TestCDIManagerGetMinMaxNums(t *testing.T) {
 			defer stopKubeController()
 			defer server.Close()
 
-			min, max, err := m.getMinMaxNums(context.Background(), tc.machineUUID, tc.modelName)
+			min, max, err := m.getMinMaxNums(context.Background(), m.defaultTenant(), tc.machineUUID, tc.modelName)
 			if tc.expectedErr {
 				if err == nil {
 					t.Error("expected error, but got none")
@@ -1151,7 +1572,7 @@ func TestCDIManagerManageCDIResourceSlices(t *testing.T) {
 			for i, availableDevice := range tc.availableDeviceCounts {
 				testSpec.AvailableDeviceCount = availableDevice
 				machines := createTestMachines(testSpec)
-				m.manageCDIResourceSlices(machines, rscontrolles)
+				m.manageCDIResourceSlices(machines, nil, rscontrolles)
 				time.Sleep(time.Second)
 				resourceslices, err := m.coreClient.ResourceV1().ResourceSlices().List(context.Background(), metav1.ListOptions{})
 				if err != nil {
@@ -1278,7 +1699,7 @@ func TestCDIManagerUpdatePool(t *testing.T) {
 				poolName := device.k8sDeviceName + "-fabric" + strconv.Itoa(tc.fabricID)
 				var updated bool
 				if _, exist := m.namedDriverResources[device.driverName]; exist {
-					updated = m.updatePool(poolName, device, tc.fabricID)
+					updated = m.updatePool(poolName, device, "", tc.fabricID)
 				}
 				if tc.expectedUpdated {
 					if !updated {
@@ -1302,6 +1723,7 @@ func TestCDIManagerGeneratePool(t *testing.T) {
 	testCases := []struct {
 		name                 string
 		k8sDeviceName        string
+		tenant               string
 		draAttributes        map[string]string
 		availableDeviceCount int
 		expectedDeviceName   string
@@ -1315,6 +1737,16 @@ func TestCDIManagerGeneratePool(t *testing.T) {
 			availableDeviceCount: 3,
 			expectedDeviceName:   "test-device-1-0",
 		},
+		{
+			name:          "When pool of a tenant is generated in multi-tenant mode",
+			k8sDeviceName: "test-device-1",
+			tenant:        "tenant-a",
+			draAttributes: map[string]string{
+				"productName": "TEST DEVICE 1",
+			},
+			availableDeviceCount: 3,
+			expectedDeviceName:   "test-device-1-0",
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -1333,7 +1765,7 @@ func TestCDIManagerGeneratePool(t *testing.T) {
 			}
 			fabricID := 1
 			generation := 0
-			pool := m.generatePool(device, fabricID, int64(generation))
+			pool := m.generatePool(device, tc.tenant, fabricID, int64(generation))
 
 			if len(pool.Slices[0].Devices) > 0 {
 				devices := pool.Slices[0].Devices
@@ -1356,6 +1788,7 @@ func TestCDIManagerGeneratePool(t *testing.T) {
 					if len(nodeSelectors.MatchExpressions) == 0 {
 						t.Errorf("NodeSelector MatchExpressions is not found")
 					}
+					var tenantFound bool
 					for _, nodeSelector := range nodeSelectors.MatchExpressions {
 						switch nodeSelector.Key {
 						case "cohdi.com/" + tc.k8sDeviceName:
@@ -1366,10 +1799,18 @@ func TestCDIManagerGeneratePool(t *testing.T) {
 							if nodeSelector.Operator != v1.NodeSelectorOpIn || !slices.Contains(nodeSelector.Values, "1") {
 								t.Errorf("unexpected nodeSelector is set in fabric key field")
 							}
+						case "cohdi.com/tenant":
+							tenantFound = true
+							if nodeSelector.Operator != v1.NodeSelectorOpIn || !slices.Contains(nodeSelector.Values, tc.tenant) {
+								t.Errorf("unexpected nodeSelector is set in tenant key field")
+							}
 						default:
 							t.Errorf("unexpected nodeSelector key is found: %s", nodeSelector.Key)
 						}
 					}
+					if len(tc.tenant) > 0 && !tenantFound {
+						t.Errorf("nodeSelector for tenant is not found")
+					}
 				}
 			}
 		})
@@ -0,0 +1,229 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+type fabricKey struct {
+	tenant   string
+	fabricID int
+}
+
+// fabricSchedule is the polling state of available devices in a fabric.
+type fabricSchedule struct {
+	tenant *tenant
+	// machineUUID is any machine in the fabric, which is used to ask FM for available devices
+	machineUUID string
+	devices     deviceList
+	interval    time.Duration
+	next        time.Time
+}
+
+// fabricScheduler polls available devices of each fabric at its own interval.
+// The interval is reset to min when the number of available devices changes,
+// and doubles up to max while it is stable.
+// A nil fabricScheduler checks every fabric in every loop.
+type fabricScheduler struct {
+	min     time.Duration
+	max     time.Duration
+	fabrics map[fabricKey]*fabricSchedule
+	now     func() time.Time
+}
+
+func newFabricScheduler(min time.Duration, max time.Duration) *fabricScheduler {
+	if max < min {
+		max = min
+	}
+	return &fabricScheduler{
+		min:     min,
+		max:     max,
+		fabrics: make(map[fabricKey]*fabricSchedule),
+		now:     time.Now,
+	}
+}
+
+// isDue reports whether available devices of the fabric should be fetched from FM.
+func (s *fabricScheduler) isDue(key fabricKey) bool {
+	if s == nil {
+		return true
+	}
+	fabric, exist := s.fabrics[key]
+	return !exist || !s.now().Before(fabric.next)
+}
+
+// devices returns a copy of the available devices fetched last time.
+func (s *fabricScheduler) devices(key fabricKey) deviceList {
+	if s == nil {
+		return nil
+	}
+	fabric, exist := s.fabrics[key]
+	if !exist {
+		return nil
+	}
+	return fabric.devices.DeepCopy()
+}
+
+// observe records the available devices fetched from FM and schedules the next poll of the fabric.
+func (s *fabricScheduler) observe(key fabricKey, t *tenant, machineUUID string, devices deviceList) {
+	if s == nil {
+		return
+	}
+	fabric, exist := s.fabrics[key]
+	if !exist {
+		fabric = &fabricSchedule{interval: s.min}
+		s.fabrics[key] = fabric
+	} else if availableCountChanged(fabric.devices, devices) {
+		fabric.interval = s.min
+		slog.Debug("available devices changed, polling the fabric faster", "tenant", key.tenant, "fabricID", key.fabricID, "interval", fabric.interval)
+	} else {
+		fabric.interval = min(fabric.interval*2, s.max)
+	}
+	fabric.tenant = t
+	fabric.machineUUID = machineUUID
+	fabric.devices = devices.DeepCopy()
+	fabric.next = s.now().Add(fabric.interval)
+}
+
+// notify records the available devices fetched on a FM event without moving the next poll,
+// so that the poll still checks the fabric as a safety net.
+func (s *fabricScheduler) notify(key fabricKey, devices deviceList) {
+	if s == nil {
+		return
+	}
+	if fabric, exist := s.fabrics[key]; exist {
+		fabric.devices = devices.DeepCopy()
+	}
+}
+
+// postpone schedules the next poll of the fabric after a failure without changing the interval.
+func (s *fabricScheduler) postpone(key fabricKey) {
+	if s == nil {
+		return
+	}
+	if fabric, exist := s.fabrics[key]; exist {
+		fabric.next = s.now().Add(fabric.interval)
+	}
+}
+
+// retain forgets the fabrics of the tenant which are not found any more.
+func (s *fabricScheduler) retain(tenant string, fabricIDs map[int]bool) {
+	if s == nil {
+		return
+	}
+	for key := range s.fabrics {
+		if key.tenant == tenant && !fabricIDs[key.fabricID] {
+			delete(s.fabrics, key)
+		}
+	}
+}
+
+// dueFabrics returns the fabrics whose next poll has come.
+// A nil fabricScheduler returns none, since the loop checks every fabric.
+func (s *fabricScheduler) dueFabrics() map[fabricKey]*fabricSchedule {
+	if s == nil {
+		return nil
+	}
+	due := make(map[fabricKey]*fabricSchedule)
+	for key, fabric := range s.fabrics {
+		if s.isDue(key) {
+			due[key] = fabric
+		}
+	}
+	return due
+}
+
+func availableCountChanged(before deviceList, after deviceList) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for modelName, device := range after {
+		if previous, exist := before[modelName]; !exist || previous.availableDeviceCount != device.availableDeviceCount {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the fabrics of the keys which are already found by the loop.
+// A nil fabricScheduler returns none, since it does not track the machines of fabrics.
+func (s *fabricScheduler) lookup(keys []fabricKey) map[fabricKey]*fabricSchedule {
+	if s == nil {
+		return nil
+	}
+	fabrics := make(map[fabricKey]*fabricSchedule)
+	for _, key := range keys {
+		fabric, exist := s.fabrics[key]
+		if !exist {
+			slog.Debug("fabric is not found yet, leaving it to the loop", "tenant", key.tenant, "fabricID", key.fabricID)
+			continue
+		}
+		fabrics[key] = fabric
+	}
+	return fabrics
+}
+
+// checkFabrics updates the pools of the fabrics which are due between the loops.
+// Node-level inputs such as machines, node groups and labels are left to the loop.
+func (m *CDIManager) checkFabrics(ctx context.Context, controllers map[string]*resourceslice.Controller) error {
+	return m.refreshFabrics(ctx, m.fabricScheduler.dueFabrics(), controllers, true)
+}
+
+// refreshFabrics fetches available devices of the fabrics from FM and updates their pools.
+// The next polls are scheduled only if polled is true, otherwise the fabrics are notified by FM and keep their schedules.
+func (m *CDIManager) refreshFabrics(ctx context.Context, fabrics map[fabricKey]*fabricSchedule, controllers map[string]*resourceslice.Controller, polled bool) error {
+	needUpdate := make(map[string]bool)
+	var errs []error
+	for key, fabric := range fabrics {
+		devices, err := m.getFabricDevices(ctx, fabric.tenant, fabric.machineUUID)
+		if err != nil {
+			if polled {
+				m.fabricScheduler.postpone(key)
+			}
+			errs = append(errs, fmt.Errorf("fabric %d of tenant %q: %w", key.fabricID, key.tenant, err))
+			continue
+		}
+		if polled {
+			m.fabricScheduler.observe(key, fabric.tenant, fabric.machineUUID, devices)
+		} else {
+			m.fabricScheduler.notify(key, devices)
+		}
+		for _, device := range devices {
+			if _, exist := m.namedDriverResources[device.driverName]; exist {
+				poolName := getPoolName(device, key.tenant, key.fabricID)
+				if m.updatePool(poolName, device, key.tenant, key.fabricID) {
+					slog.Info("pool update", "poolName", poolName, "generation", m.namedDriverResources[device.driverName].Pools[poolName].Generation, "driver", device.driverName)
+					needUpdate[device.driverName] = true
+				}
+			}
+		}
+	}
+	m.updateControllers(controllers, needUpdate)
+	if m.cdiOptions.dryRun && len(needUpdate) > 0 {
+		if err := m.reportDryRunPools(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
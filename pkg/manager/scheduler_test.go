@@ -0,0 +1,130 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFabricSchedulerObserve(t *testing.T) {
+	testCases := []struct {
+		name              string
+		availableCounts   []int
+		expectedIntervals []time.Duration
+	}{
+		{
+			name:              "When available devices are stable",
+			availableCounts:   []int{2, 2, 2, 2, 2},
+			expectedIntervals: []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second},
+		},
+		{
+			name:              "When available devices change",
+			availableCounts:   []int{2, 2, 2, 1, 1},
+			expectedIntervals: []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 5 * time.Second, 10 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			s := newFabricScheduler(5*time.Second, 30*time.Second)
+			s.now = func() time.Time { return now }
+			key := fabricKey{fabricID: 1}
+			for i, count := range tc.availableCounts {
+				if !s.isDue(key) {
+					t.Fatalf("fabric is not due at poll %d", i)
+				}
+				s.observe(key, nil, "", deviceList{"model": &device{availableDeviceCount: count}})
+				if s.fabrics[key].interval != tc.expectedIntervals[i] {
+					t.Errorf("unexpected interval at poll %d, expected %s but got %s", i, tc.expectedIntervals[i], s.fabrics[key].interval)
+				}
+				if s.isDue(key) {
+					t.Errorf("fabric is due right after poll %d", i)
+				}
+				now = now.Add(s.fabrics[key].interval)
+			}
+		})
+	}
+}
+
+func TestFabricSchedulerNil(t *testing.T) {
+	var s *fabricScheduler
+	key := fabricKey{fabricID: 1}
+	if !s.isDue(key) {
+		t.Error("fabric is not due in nil scheduler")
+	}
+	s.observe(key, nil, "", deviceList{"model": &device{availableDeviceCount: 1}})
+	s.notify(key, deviceList{"model": &device{availableDeviceCount: 1}})
+	s.postpone(key)
+	s.retain("", map[int]bool{})
+	if devices := s.devices(key); devices != nil {
+		t.Errorf("unexpected devices in nil scheduler: %v", devices)
+	}
+	if due := s.dueFabrics(); len(due) != 0 {
+		t.Errorf("unexpected due fabrics in nil scheduler: %v", due)
+	}
+	if fabrics := s.lookup([]fabricKey{key}); len(fabrics) != 0 {
+		t.Errorf("unexpected fabrics in nil scheduler: %v", fabrics)
+	}
+}
+
+func TestCheckFabrics(t *testing.T) {
+	testSpec := config.TestSpec{
+		UseCapiBmh:         false,
+		UseCM:              false,
+		DRAenabled:         true,
+		CaseDriverResource: CaseDriverResourceEmpty,
+	}
+	m, server, stopKubeController := createTestManager(t, testSpec)
+	defer server.Close()
+	defer stopKubeController()
+	now := time.Now()
+	m.fabricScheduler = newFabricScheduler(5*time.Second, time.Minute)
+	m.fabricScheduler.now = func() time.Time { return now }
+	controllers := createTestResourceSliceControllers(t, m.coreClient)
+
+	if err := m.startCheckResourcePoolLoop(context.Background(), controllers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.fabricScheduler.fabrics) != fabricIdNum {
+		t.Fatalf("unexpected fabric num, expected %d but got %d", fabricIdNum, len(m.fabricScheduler.fabrics))
+	}
+
+	// Only the due fabric is fetched again, so only its pool is restored
+	dueKey := fabricKey{fabricID: 1}
+	notDueKey := fabricKey{fabricID: 2}
+	now = now.Add(5 * time.Second)
+	m.fabricScheduler.fabrics[notDueKey].next = now.Add(time.Second)
+	pools := m.namedDriverResources["test-driver-1"].Pools
+	delete(pools, "test-device-1-fabric1")
+	delete(pools, "test-device-1-fabric2")
+
+	if err := m.checkFabrics(context.Background(), controllers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, exist := pools["test-device-1-fabric1"]; !exist {
+		t.Error("pool of the due fabric is not updated")
+	}
+	if _, exist := pools["test-device-1-fabric2"]; exist {
+		t.Error("pool of the fabric which is not due is updated")
+	}
+	if interval := m.fabricScheduler.fabrics[dueKey].interval; interval != 10*time.Second {
+		t.Errorf("unexpected interval of the stable fabric, expected %s but got %s", 10*time.Second, interval)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+const withdrawTimeOut = 5 * time.Second
+
+var fabricPoolRegexp = regexp.MustCompile(`-fabric[0-9]+$`)
+
+// withGracePeriod returns a context which is canceled not when ctx is done but gracePeriod later,
+// so that the loop in flight on shutdown can finish writing nodes and ResourceSlices.
+func withGracePeriod(ctx context.Context, gracePeriod time.Duration) (context.Context, context.CancelFunc) {
+	workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-workCtx.Done():
+			return
+		}
+		timer := time.NewTimer(gracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			slog.Warn("Grace period is over, interrupting the loop", "gracePeriod", gracePeriod)
+			cancel()
+		case <-workCtx.Done():
+		}
+	}()
+	return workCtx, cancel
+}
+
+// shutdown stops the ResourceSlice controllers, withdraws the pools if configured and revokes IM tokens.
+// It is called after the last loop has finished.
+func (m *CDIManager) shutdown(ctx context.Context, controllers map[string]*resourceslice.Controller) {
+	slog.Info("Shutting down")
+	for driverName, controller := range controllers {
+		// Stop waits for the ResourceSlice being written
+		controller.Stop()
+		slog.Debug("ResourceSlice controller stopped", "driverName", driverName)
+	}
+	if m.cdiOptions.withdrawPools && !m.cdiOptions.dryRun {
+		withdrawCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), withdrawTimeOut)
+		defer cancel()
+		if err := m.withdrawPools(withdrawCtx); err != nil {
+			slog.Error("Failed to withdraw pools", "error", err)
+		}
+	}
+	m.revokeTokens()
+	slog.Info("Shutdown completed")
+}
+
+// withdrawPools deletes the ResourceSlices of CDI fabric pools, including the ones published before restart,
+// so that no device of the driver is left in the cluster on uninstall.
+func (m *CDIManager) withdrawPools(ctx context.Context) error {
+	resourceSlices, err := m.coreClient.ResourceV1().ResourceSlices().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list ResourceSlices: %w", err)
+	}
+	var errs []error
+	for _, resourceSlice := range resourceSlices.Items {
+		if !m.isFabricPool(resourceSlice.Spec.Driver, resourceSlice.Spec.Pool.Name) {
+			continue
+		}
+		err := m.coreClient.ResourceV1().ResourceSlices().Delete(ctx, resourceSlice.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete ResourceSlice %s: %w", resourceSlice.Name, err))
+			continue
+		}
+		slog.Info("pool withdrawn", "poolName", resourceSlice.Spec.Pool.Name, "resourceSlice", resourceSlice.Name, "driver", resourceSlice.Spec.Driver)
+	}
+	return errors.Join(errs...)
+}
+
+// isFabricPool reports whether a pool is published by CDI_DRA, not by the vendor DRA driver of the same name.
+func (m *CDIManager) isFabricPool(driverName string, poolName string) bool {
+	if !fabricPoolRegexp.MatchString(poolName) {
+		return false
+	}
+	for _, deviceInfo := range m.deviceInfos {
+		if deviceInfo.DriverName == driverName && strings.HasPrefix(poolName, deviceInfo.K8sDeviceName+"-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manager
+
+import (
+	"cdi_dra/pkg/config"
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	resourceapi "k8s.io/api/resource/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/dynamic-resource-allocation/resourceslice"
+)
+
+func TestWithGracePeriod(t *testing.T) {
+	testCases := []struct {
+		name             string
+		gracePeriod      time.Duration
+		cancelParent     bool
+		expectedCanceled bool
+	}{
+		{
+			name:             "When the parent is not canceled",
+			gracePeriod:      10 * time.Millisecond,
+			expectedCanceled: false,
+		},
+		{
+			name:             "When the parent is canceled and the grace period is not over",
+			gracePeriod:      time.Minute,
+			cancelParent:     true,
+			expectedCanceled: false,
+		},
+		{
+			name:             "When the parent is canceled and the grace period is over",
+			gracePeriod:      10 * time.Millisecond,
+			cancelParent:     true,
+			expectedCanceled: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent, cancelParent := context.WithCancel(context.Background())
+			defer cancelParent()
+			workCtx, cancelWork := withGracePeriod(parent, tc.gracePeriod)
+			defer cancelWork()
+			if tc.cancelParent {
+				cancelParent()
+			}
+			select {
+			case <-workCtx.Done():
+				if !tc.expectedCanceled {
+					t.Error("unexpected cancel of work context")
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tc.expectedCanceled {
+					t.Error("work context is not canceled after the grace period")
+				}
+			}
+		})
+	}
+}
+
+func TestCDIManagerShutdown(t *testing.T) {
+	testCases := []struct {
+		name               string
+		withdrawPools      bool
+		dryRun             bool
+		expectedRemainPool []string
+	}{
+		{
+			name:               "When pools are not withdrawn",
+			withdrawPools:      false,
+			expectedRemainPool: []string{"test-device-1-fabric1", "test-device-3-fabric2", "test-node-0", "vendor-device-fabric1"},
+		},
+		{
+			name:               "When pools are withdrawn",
+			withdrawPools:      true,
+			expectedRemainPool: []string{"test-node-0", "vendor-device-fabric1"},
+		},
+		{
+			name:               "When pools are withdrawn in dry-run mode",
+			withdrawPools:      true,
+			dryRun:             true,
+			expectedRemainPool: []string{"test-device-1-fabric1", "test-device-3-fabric2", "test-node-0", "vendor-device-fabric1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			testSpec := config.TestSpec{
+				UseCapiBmh:         false,
+				UseCM:              false,
+				DRAenabled:         true,
+				CaseDriverResource: CaseDriverResourceEmpty,
+				CaseDeviceInfo:     config.CaseDevInfoCorrect,
+			}
+			m, server, stopKubeController := createTestManager(t, testSpec)
+			defer server.Close()
+			defer stopKubeController()
+			m.cdiOptions.withdrawPools = tc.withdrawPools
+			m.cdiOptions.dryRun = tc.dryRun
+
+			// Pools of the vendor DRA driver must be kept
+			for _, resourceSlice := range []*resourceapi.ResourceSlice{
+				createTestResourceSlice("test-driver-1", "test-device-1-fabric1", "test-device-1-0"),
+				createTestResourceSlice("test-driver-2", "test-device-3-fabric2", "test-device-3-0"),
+				createTestResourceSlice("test-driver-1", "test-node-0", "gpu-0"),
+				createTestResourceSlice("test-driver-1", "vendor-device-fabric1", "gpu-1"),
+			} {
+				if _, err := m.coreClient.ResourceV1().ResourceSlices().Create(context.Background(), resourceSlice, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("failed to create ResourceSlice: %v", err)
+				}
+			}
+			controller, err := resourceslice.StartController(context.Background(), resourceslice.Options{
+				DriverName: "test-driver-3",
+				KubeClient: m.coreClient,
+			})
+			if err != nil {
+				t.Fatalf("failed to start controller: %v", err)
+			}
+
+			m.shutdown(context.Background(), map[string]*resourceslice.Controller{"test-driver-3": controller})
+
+			resourceSlices, err := m.coreClient.ResourceV1().ResourceSlices().List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error in kube client List: %v", err)
+			}
+			var remainPool []string
+			for _, resourceSlice := range resourceSlices.Items {
+				remainPool = append(remainPool, resourceSlice.Spec.Pool.Name)
+			}
+			slices.Sort(remainPool)
+			if !slices.Equal(remainPool, tc.expectedRemainPool) {
+				t.Errorf("unexpected remaining pools, expected %v but got %v", tc.expectedRemainPool, remainPool)
+			}
+		})
+	}
+}
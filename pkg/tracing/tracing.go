@@ -0,0 +1,109 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName  = "cdi_dra"
+	serviceName = "composable-dra-driver"
+
+	// RequestIDKey is the span attribute of request IDs sent to CDI
+	RequestIDKey = attribute.Key("cdi.request_id")
+)
+
+// ShutdownFunc flushes the spans and stops the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// ParseEndpoint checks the URL of OTLP/HTTP collector.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otlp endpoint: %w", err)
+	}
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		return nil, fmt.Errorf("otlp endpoint format must be set starting http:// or https://")
+	}
+	if len(endpointURL.Host) == 0 {
+		return nil, fmt.Errorf("otlp endpoint must have a host")
+	}
+	return endpointURL, nil
+}
+
+// Setup exports traces to the OTLP/HTTP endpoint and propagates them to CDI with W3C trace context.
+// Tracing is disabled if endpoint is empty. The path defaults to /v1/traces if not set in endpoint.
+func Setup(ctx context.Context, endpoint string) (ShutdownFunc, error) {
+	if len(endpoint) == 0 {
+		return func(context.Context) error { return nil }, nil
+	}
+	endpointURL, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpointURL.Path) == 0 || endpointURL.Path == "/" {
+		endpointURL.Path = "/v1/traces"
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpointURL.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("failed to export traces", "error", err)
+	}))
+	slog.Info("tracing is enabled", "endpoint", endpointURL.String())
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err in the span if any, and ends it.
+func End(span trace.Span, err error) {
+	RecordError(span, err)
+	span.End()
+}
+
+// RecordError marks the span as failed with err. Nothing is recorded if err is nil.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 The CoHDI Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		name           string
+		endpoint       string
+		expectedErr    bool
+		expectedErrMsg string
+	}{
+		{
+			name:        "When endpoint is not set",
+			endpoint:    "",
+			expectedErr: false,
+		},
+		{
+			name:        "When endpoint is set",
+			endpoint:    "http://127.0.0.1:4318",
+			expectedErr: false,
+		},
+		{
+			name:           "When endpoint has unsupported scheme",
+			endpoint:       "grpc://127.0.0.1:4317",
+			expectedErr:    true,
+			expectedErrMsg: "otlp endpoint format must be set starting http:// or https://",
+		},
+		{
+			name:           "When endpoint has no host",
+			endpoint:       "http:///v1/traces",
+			expectedErr:    true,
+			expectedErrMsg: "otlp endpoint must have a host",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			prevProvider := otel.GetTracerProvider()
+			defer otel.SetTracerProvider(prevProvider)
+
+			shutdown, err := Setup(context.Background(), tc.endpoint)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected error, but got none")
+				}
+				if err.Error() != tc.expectedErrMsg {
+					t.Errorf("unexpected error message, expected %s but got %s", tc.expectedErrMsg, err.Error())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("unexpected error in shutdown: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "When span succeeds",
+			err:            nil,
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:           "When span fails",
+			err:            fmt.Errorf("FM machine list API failed"),
+			expectedStatus: codes.Error,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			prevProvider := otel.GetTracerProvider()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			defer otel.SetTracerProvider(prevProvider)
+
+			_, span := Start(context.Background(), "getMachineList", RequestIDKey.String("abcdef"))
+			End(span, tc.err)
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("unexpected number of spans: %d", len(ended))
+			}
+			if ended[0].Status().Code != tc.expectedStatus {
+				t.Errorf("unexpected status, expected %v but got %v", tc.expectedStatus, ended[0].Status().Code)
+			}
+			if tc.err != nil && ended[0].Status().Description != tc.err.Error() {
+				t.Errorf("unexpected status description: %s", ended[0].Status().Description)
+			}
+		})
+	}
+}